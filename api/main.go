@@ -20,11 +20,29 @@ import (
 )
 
 const (
-	maxListSize    = 150
-	defaultListMax = 50
-	apiVersion     = "1.0.0 Beta"
-	JSONSPEC       = "./dist/apiDoc.json"
-	YAMLSPEC       = "./dist/apiDoc.yaml"
+	maxListSize          = 150
+	defaultListMax       = 50
+	maxNutrientFilters   = 10
+	defaultStatsBins     = 10
+	maxStatsBins         = 100
+	maxIngredients       = 50
+	defaultCandidates    = 5
+	maxCandidates        = 25
+	defaultIndexBatch    = 500
+	maxIndexBatch        = 5000
+	maxCompare           = 10
+	defaultSubstitutes   = 10
+	defaultOptimizeFoods = 25
+	maxOptimizeFoods     = 100
+	defaultMaxWeight     = 500
+	defaultMealPlanDays  = 7
+	maxMealPlanDays      = 14
+	defaultMealsPerDay   = 3
+	maxMealsPerDay       = 6
+	maxDiaryDays         = 366
+	apiVersion           = "1.0.0 Beta"
+	JSONSPEC             = "./dist/apiDoc.json"
+	YAMLSPEC             = "./dist/apiDoc.yaml"
 )
 
 var (
@@ -73,6 +91,7 @@ func main() {
 		}
 	}
 	authMiddleware := u.AuthMiddleware(cs.CouchDb.Bucket, dc)
+	userMiddleware := u.UserMiddleware(cs.CouchDb.Bucket, dc)
 	//router := gin.Default()
 	router := gin.New()
 	router.Use(gin.Logger())
@@ -83,22 +102,63 @@ func main() {
 	{
 		ag := v1.Group("/")
 		ag.Use(authMiddleware.MiddlewareFunc())
+		ug := v1.Group("/")
+		ug.Use(userMiddleware.MiddlewareFunc())
 		v1.POST("/login", authMiddleware.LoginHandler)
 		ag.PUT("/user", userAdd)
 		ag.DELETE("/user/:id", userDelete)
 		ag.GET("/user/:id", userList)
 		ag.GET("/users", userList)
+		ag.POST("/recipes/recompute", recipeRecompute)
+		ag.POST("/ingredients/index", ingredientsIndex)
+		ag.POST("/diets/index", dietsIndex)
+		ag.POST("/scores/index", scoresIndex)
+		ag.POST("/quality/index", qualityIndex)
+		ag.POST("/vectors/index", vectorsIndex)
+		ug.POST("/recipe", recipeAdd)
+		ug.PUT("/recipe/:id", recipeUpdate)
+		ug.GET("/recipe/:id", recipeGet)
+		ug.DELETE("/recipe/:id", recipeDelete)
+		ug.GET("/recipes", recipeList)
+		ug.POST("/mealplan", mealPlanAdd)
+		ug.GET("/mealplan/:id", mealPlanGet)
+		ug.DELETE("/mealplan/:id", mealPlanDelete)
+		ug.POST("/mealplan/:id/day/:day", mealPlanDay)
+		ug.GET("/mealplans", mealPlanList)
+		ug.POST("/diary/entry", diaryAdd)
+		ug.PUT("/diary/entry/:id", diaryUpdate)
+		ug.GET("/diary/entry/:id", diaryGet)
+		ug.DELETE("/diary/entry/:id", diaryDelete)
+		ug.GET("/diary/entries", diaryList)
+		ug.GET("/diary/totals", diaryTotals)
+		ug.GET("/diary/trends", diaryTrends)
 		v1.GET("/nutrients/food/:id", nutrientFdcID)
 		v1.GET("/nutrients/foods", nutrientFdcIDs)
 		v1.GET("/food/:id", foodFdcID)
+		v1.GET("/food/:id/label", foodLabel)
+		v1.GET("/food/:id/allergens", foodAllergens)
+		v1.GET("/food/:id/similar", foodSimilar)
+		v1.GET("/food/:id/substitutes", foodSubstitutes)
+		v1.GET("/food/:id/inputs", foodInputs)
+		v1.GET("/food/:id/portions", foodPortions)
+		v1.POST("/diet/optimize", dietOptimize)
 		v1.GET("/foods", foodFdcIds)
 		v1.GET("/foods/browse", foodsBrowse)
+		v1.GET("/foods/compare", foodsCompare)
 		v1.GET("/foods/search", foodsSearchGet)
 		v1.POST("/foods/search", foodsSearchPost)
 		v1.GET("/foods/count/:doctype", countsGet)
 		v1.GET("/dictionary/:type", dictionaryBrowse)
 		v1.GET("/docs/:type", specDoc)
 		v1.POST("/nutrients/report", nutrientReportPost)
+		v1.GET("/nutrients/stats/:nutrientno", nutrientStats)
+		v1.GET("/intakes/food/:id", intakeFoods)
+		v1.GET("/intakes/foods", intakeFoods)
+		v1.GET("/intakes/references", intakeReferences)
+		v1.POST("/recipes/analyze", recipeAnalyze)
+		v1.POST("/ingredients/parse", ingredientsParse)
+		v1.GET("/quality/food/:id", qualityFood)
+		v1.GET("/quality/foods", qualityBrowse)
 	}
 	doc.GET("/", func(c *gin.Context) {
 		c.HTML(http.StatusOK, "apiDoc.html", nil)
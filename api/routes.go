@@ -6,14 +6,35 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"math"
+	"math/rand"
 	"net/http"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	auth "github.com/littlebunch/fdc-api/auth"
+	"github.com/littlebunch/fdc-api/compare"
+	"github.com/littlebunch/fdc-api/diary"
+	"github.com/littlebunch/fdc-api/diet"
+	"github.com/littlebunch/fdc-api/dri"
+	"github.com/littlebunch/fdc-api/ds/cb"
+	"github.com/littlebunch/fdc-api/fndds"
+	"github.com/littlebunch/fdc-api/ingredient"
+	"github.com/littlebunch/fdc-api/label"
+	"github.com/littlebunch/fdc-api/mealplan"
 	fdc "github.com/littlebunch/fdc-api/model"
+	"github.com/littlebunch/fdc-api/optimize"
+	"github.com/littlebunch/fdc-api/provenance"
+	"github.com/littlebunch/fdc-api/quality"
+	"github.com/littlebunch/fdc-api/recipe"
+	"github.com/littlebunch/fdc-api/score"
+	"github.com/littlebunch/fdc-api/similar"
+	"github.com/littlebunch/fdc-api/substitute"
+	"github.com/littlebunch/fdc-api/units"
 )
 
 var isUpc = regexp.MustCompile(`^[0-9]+$`)
@@ -60,12 +81,89 @@ func foodFdcID(c *gin.Context) {
 	if err != nil {
 		errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": "No food found!"})
 	}
+	if len(f.IngredientList) == 0 && f.Ingredients != "" {
+		f.IngredientList = ingredient.ParseStatement(f.Ingredients)
+	}
+	// diets and scores which haven't been indexed are computed on request
+	diets := c.Query("diets") == "true" && len(f.Diets) == 0
+	scores := c.Query("scores") == "true" && f.Scores == nil
+	if diets || scores {
+		if nd, err := foodNutrients(f.FdcID); err != nil {
+			log.Printf("%s: %v\n", f.FdcID, err)
+		} else {
+			if diets {
+				f.Diets = foodDiets(f, nd)
+			}
+			if scores {
+				f.Scores = foodScores(f, nd)
+			}
+		}
+	}
 	items = append(items, f)
 	results := fdc.BrowseResult{Count: 1, Start: 0, Max: 1, Items: items}
 	c.JSON(http.StatusOK, results)
 	return
 }
 
+// foodLabel renders a Nutrition Facts label for a food as SVG or HTML in the standard, linear or
+// dual column style.  The serving is the food's first serving unless an amount and unit are requested.
+func foodLabel(c *gin.Context) {
+	var (
+		f fdc.Food
+	)
+	q := c.Param("id")
+	format := c.DefaultQuery("format", "html")
+	if format != "html" && format != "svg" {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "format parameter must be 'html' or 'svg'"})
+		return
+	}
+	style := label.Style(c.DefaultQuery("style", string(label.STANDARD)))
+	if style != label.STANDARD && style != label.LINEAR && style != label.DUAL {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("style parameter must be '%s', '%s' or '%s'", label.STANDARD, label.LINEAR, label.DUAL)})
+		return
+	}
+	perContainer := 0.0
+	if sp := c.Query("servings"); sp != "" {
+		if perContainer, err = parseFinite(sp); err != nil || perContainer <= 0 {
+			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "servings parameter must be a number greater than 0"})
+			return
+		}
+	}
+	amount, unit, err := amountParams(c)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	if len(q) > 7 {
+		q, _ = upcTofdcid(q, cs.CouchDb.Bucket)
+	}
+	if err = foodDoc(q, &f); err != nil {
+		errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": "No food found!"})
+		return
+	}
+	nd, err := foodNutrients(f.FdcID)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Data error %v", err)})
+		return
+	}
+	size, weight, err := servingSize(f, amount, unit)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	facts := label.New(f.Description, fdc.NewNutrientValues(nd), size, weight, perContainer, style)
+	if format == "svg" {
+		c.Data(http.StatusOK, "image/svg+xml; charset=utf-8", []byte(facts.SVG(style)))
+		return
+	}
+	h, err := facts.HTML(style)
+	if err != nil {
+		errorout(c, http.StatusInternalServerError, gin.H{"status": http.StatusInternalServerError, "message": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(h))
+}
+
 // returns foods in a BrowseResult for a list of fdcIds or upcs.  If an id looks like a upc it is converted
 // to a fdcId.
 func foodFdcIds(c *gin.Context) {
@@ -87,6 +185,27 @@ func foodFdcIds(c *gin.Context) {
 
 }
 
+// foodAllergens returns the major food allergens declared in a food's ingredient statement
+// or, for foods without one, it's description
+func foodAllergens(c *gin.Context) {
+	var f fdc.Food
+	id := c.Param("id")
+	if len(id) > 7 && isUpc.MatchString(id) {
+		id, _ = upcTofdcid(id, cs.CouchDb.Bucket)
+	}
+	if err := foodDoc(id, &f); err != nil {
+		errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": "No food found!"})
+		return
+	}
+	text := f.Ingredients
+	if text == "" {
+		text = f.Description
+	}
+	ar := fdc.AllergenReport{FdcID: f.FdcID, Description: f.Description, Ingredients: f.Ingredients}
+	ar.Contains, ar.MayContain = ingredient.DetectAllergens(text)
+	c.JSON(http.StatusOK, ar)
+}
+
 // returns a dictionary list which can be nutrients (NUT), derivations (DERV), food categories (FGGPC)
 func dictionaryBrowse(c *gin.Context) {
 	var (
@@ -98,8 +217,8 @@ func dictionaryBrowse(c *gin.Context) {
 	if t == "" {
 		t = dt.ToString(fdc.NUT)
 	}
-	if t != "NUT" && t != "DERV" && t != "FGSR" && t != "FGFNDDS" && t != "FGGPC" {
-		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "one of type parameter is required: NUT, DERV, FGSR,FGFNDDS, FGGPC"})
+	if t != "NUT" && t != "DERV" && t != "FGSR" && t != "FGFNDDS" && t != "FGGPC" && t != "UNIT" {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "one of type parameter is required: NUT, DERV, FGSR,FGFNDDS, FGGPC, UNIT"})
 		return
 	}
 	if max, err = strconv.ParseInt(c.Query("max"), 10, 32); err != nil {
@@ -112,8 +231,15 @@ func dictionaryBrowse(c *gin.Context) {
 		page = 0
 	}
 	offset := page * max
-	items, err := dc.GetDictionary(cs.CouchDb.Bucket, t, offset, max)
-	if err != nil {
+	var items []interface{}
+	// units of measure come from the units package rather than the datastore
+	if t == dt.ToString(fdc.UNIT) {
+		for i, u := range units.Catalog() {
+			if int64(i) >= offset && int64(i) < offset+max {
+				items = append(items, u)
+			}
+		}
+	} else if items, err = dc.GetDictionary(cs.CouchDb.Bucket, t, offset, max); err != nil {
 		errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": "Error."})
 		return
 	}
@@ -124,7 +250,7 @@ func dictionaryBrowse(c *gin.Context) {
 
 // returns nutrients for a specified foods identified by fdcId or UPC
 // if an optional n parameter is provided then limit nutrients returned to the
-// nutrientno's in the n paramter array
+// nutrientno's in the n paramter array.  provenance=true adds each value's provenance.
 func nutrientFdcID(c *gin.Context) {
 	var (
 		q   string
@@ -139,10 +265,17 @@ func nutrientFdcID(c *gin.Context) {
 		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "a FDC id in the q parameter is required"})
 		return
 	}
+	amount, unit, err := amountParams(c)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
 	// replace UPC with fdcId
 	if len(q) > 7 {
 		q, _ = upcTofdcid(q, cs.CouchDb.Bucket)
 	}
+	prov := c.Query("provenance") == "true"
+	fields := provenanceFields(prov)
 	// build query for one or more nutrient #'s otherwise build a query to return all nutrients
 	if n := c.QueryArray("n"); len(n) > 0 {
 
@@ -151,10 +284,10 @@ func nutrientFdcID(c *gin.Context) {
 			nids = append(nids, fmt.Sprintf("%s_%s", q, n[i]))
 		}
 		qids, _ := buildIDList(nids)
-		q = fmt.Sprintf("SELECT fdcId,upc,portion,portionValue as valuePerPortion,foodDescription,company,category,valuePer100UnitServing,unit,nutrientNumber,nutrientName from %s as nutrient WHERE type=\"%s\" AND meta(nutrient).id in %s", cs.CouchDb.Bucket, dt.ToString(fdc.NUTDATA), qids)
+		q = fmt.Sprintf("SELECT fdcId,upc,portion,portionValue as valuePerPortion,foodDescription,company,category,valuePer100UnitServing,unit,nutrientNumber,nutrientName%s from %s as nutrient WHERE type=\"%s\" AND meta(nutrient).id in %s", fields, cs.CouchDb.Bucket, dt.ToString(fdc.NUTDATA), qids)
 	} else {
 
-		q = fmt.Sprintf("SELECT fdcId,upc,portion,portionValue as valuePerPortion,foodDescription,company,category,valuePer100UnitServing,unit,nutrientNumber,nutrientName from %s as nutrient WHERE type=\"%s\" AND fdcId = \"%s\"", cs.CouchDb.Bucket, dt.ToString(fdc.NUTDATA), q)
+		q = fmt.Sprintf("SELECT fdcId,upc,portion,portionValue as valuePerPortion,foodDescription,company,category,valuePer100UnitServing,unit,nutrientNumber,nutrientName%s from %s as nutrient WHERE type=\"%s\" AND fdcId = \"%s\"", fields, cs.CouchDb.Bucket, dt.ToString(fdc.NUTDATA), q)
 	}
 	dc.Query(q, &nd)
 	haveFood := false
@@ -162,6 +295,9 @@ func nutrientFdcID(c *gin.Context) {
 		b, _ := json.Marshal(nd[i])
 		ndi = fdc.NutrientFoodBrowseItem{}
 		json.Unmarshal(b, &ndi)
+		if prov {
+			ndi.Provenance = nutrientProvenance(b)
+		}
 		if !haveFood {
 			json.Unmarshal(b, &ndd)
 			haveFood = true
@@ -169,6 +305,12 @@ func nutrientFdcID(c *gin.Context) {
 		ndb = append(ndb, ndi)
 	}
 	results := fdc.NutrientFoodBrowse{FdcID: ndd.FdcID, Portion: ndd.Portion, Description: ndd.Description, Upc: ndd.Upc, Nutrients: ndb}
+	if amount > 0 && results.FdcID != "" {
+		if err = scaleNutrients(&results, amount, unit); err != nil {
+			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+			return
+		}
+	}
 	c.JSON(http.StatusOK, results)
 
 	return
@@ -176,7 +318,7 @@ func nutrientFdcID(c *gin.Context) {
 
 // returns nutrients for a specified list of foods identified by fdcId
 // if an optional n parameter is provided then limit nutrients returned to the
-// nutrientno in the n paramter
+// nutrientno in the n paramter.  provenance=true adds each value's provenance.
 func nutrientFdcIDs(c *gin.Context) {
 	var (
 		q       string
@@ -193,6 +335,13 @@ func nutrientFdcIDs(c *gin.Context) {
 		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "Cannot request more than 24 id's"})
 		return
 	}
+	amount, unit, err := amountParams(c)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	prov := c.Query("provenance") == "true"
+	fields := provenanceFields(prov)
 	// create nutrient data ids
 	if n := c.QueryArray("n"); len(n) > 0 {
 		var nids []string
@@ -202,11 +351,11 @@ func nutrientFdcIDs(c *gin.Context) {
 			}
 		}
 		qids, _ := buildIDList(nids)
-		q = fmt.Sprintf("SELECT fdcId,upc,servingSizes,foodDescription,company,category,derivation,valuePer100UnitServing,portion,portionValue as valuePerPortion,unit,nutrientNumber,nutrientName from %s as nutrient WHERE type=\"%s\" AND meta(nutrient).id in %s order by fdcId", cs.CouchDb.Bucket, dt.ToString(fdc.NUTDATA), qids)
+		q = fmt.Sprintf("SELECT fdcId,upc,servingSizes,foodDescription,company,category,valuePer100UnitServing,portion,portionValue as valuePerPortion,unit,nutrientNumber,nutrientName%s from %s as nutrient WHERE type=\"%s\" AND meta(nutrient).id in %s order by fdcId", fields, cs.CouchDb.Bucket, dt.ToString(fdc.NUTDATA), qids)
 
 	} else {
 		qids, _ := buildIDList(ids)
-		q = fmt.Sprintf("SELECT fdcId,upc,servingSizes,foodDescription,company,category,derivation,valuePer100UnitServing,portion,portionValue as valuePerPortion,unit,nutrientNumber,nutrientName from %s as nutrient WHERE type=\"%s\" AND fdcId in %s order by fdcId", cs.CouchDb.Bucket, dt.ToString(fdc.NUTDATA), qids)
+		q = fmt.Sprintf("SELECT fdcId,upc,servingSizes,foodDescription,company,category,valuePer100UnitServing,portion,portionValue as valuePerPortion,unit,nutrientNumber,nutrientName%s from %s as nutrient WHERE type=\"%s\" AND fdcId in %s order by fdcId", fields, cs.CouchDb.Bucket, dt.ToString(fdc.NUTDATA), qids)
 	}
 	dc.Query(q, &nd)
 	// convert each row to the types NutrientFoodBrowse and NutrientFoodBrowseItem
@@ -217,6 +366,9 @@ func nutrientFdcIDs(c *gin.Context) {
 		//get the NutrientFoodBrowseItem nfbi
 		nfbi = fdc.NutrientFoodBrowseItem{}
 		json.Unmarshal(b, &nfbi)
+		if prov {
+			nfbi.Provenance = nutrientProvenance(b)
+		}
 		if nf.FdcID != nfb.FdcID {
 			// add the current NutrientFoodBrowse nfb to the NutrientFoodBrowseItem array nfbs to be returned
 			if nfb.FdcID != "" {
@@ -241,6 +393,17 @@ func nutrientFdcIDs(c *gin.Context) {
 	}
 	nfb.Nutrients = ndb
 	nfbs = append(nfbs, nfb)
+	if amount > 0 {
+		for i := range nfbs {
+			if nfbs[i].FdcID == "" {
+				continue
+			}
+			if err = scaleNutrients(&nfbs[i], amount, unit); err != nil {
+				errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("%s: %v", nfbs[i].FdcID, err)})
+				return
+			}
+		}
+	}
 	c.JSON(http.StatusOK, nfbs)
 	return
 }
@@ -255,8 +418,10 @@ func foodsBrowse(c *gin.Context) {
 	if sort = c.Query("sort"); sort == "" {
 		sort = "fdcId"
 	}
-	if sort != "" && sort != "foodDescription" && sort != "company" && sort != "fdcId" {
-		errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": "Unrecognized sort parameter.  Must be 'company', 'name' or 'fdcId'"})
+	if field, ok := fdc.ScoreSorts[sort]; ok {
+		sort = field
+	} else if sort != "foodDescription" && sort != "company" && sort != "fdcId" {
+		errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": fmt.Sprintf("Unrecognized sort parameter.  Must be 'company', 'name', 'fdcId', '%s', '%s' or '%s'", fdc.NUTRISCORE, fdc.HEALTHSTAR, fdc.NRF)})
 		return
 	}
 	order, err := sortOrder(c.Query("order"))
@@ -298,6 +463,22 @@ func foodsBrowse(c *gin.Context) {
 	if source != "" {
 		where = where + sourceFilter(source)
 	}
+	allergens := listParam(c, "excludeAllergens")
+	if err = ingredient.ValidAllergens(allergens); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	where += cb.AllergenFilter("", allergens)
+	where += cb.IngredientFilter("", c.Query("firstIngredient"), c.Query("ingredient"))
+	diets := listParam(c, "diet")
+	if err = diet.Valid(diets); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	where += cb.DietFilter("", diets)
+	if c.Query("excludeFlagged") == "true" {
+		where += qualityFilter()
+	}
 	foods, err := dc.Browse(cs.CouchDb.Bucket, where, offset, max, sort, order)
 	if err != nil {
 		errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": fmt.Sprintf("Query error %v", err)})
@@ -307,285 +488,2548 @@ func foodsBrowse(c *gin.Context) {
 	c.JSON(http.StatusOK, results)
 }
 
-// foodsSearch runs a simple keyword search and returns a BrowseResult
-func foodsSearchGet(c *gin.Context) {
+// foodSimilar returns the foods with the nutrient profiles most similar to a food's by cosine
+// similarity or Euclidean distance between nutrient vectors.  Similar foods may be restricted to the
+// food's food group, a data source and to foods with less or more of nutrients than the food.
+func foodSimilar(c *gin.Context) {
 	var (
-		max, page int
-		err       error
+		f  fdc.Food
+		sf []fdc.SimilarFood
 	)
-	// check for a query
-	q := c.Query("q")
-	if q == "" {
-		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "A search string in the q parameter is required"})
-		return
-	}
-	if max, err = strconv.Atoi(c.Query("max")); err != nil {
-		max = defaultListMax
+	id := c.Param("id")
+	if len(id) > 7 && isUpc.MatchString(id) {
+		id, _ = upcTofdcid(id, cs.CouchDb.Bucket)
 	}
-	if max > maxListSize {
-		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("max parameter %d exceeds maximum allowed size of %d", max, maxListSize)})
+	if err := foodDoc(id, &f); err != nil {
+		errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": fmt.Sprintf("No food found for %s", id)})
 		return
 	}
-	if page, err = strconv.Atoi(c.Query("page")); err != nil {
-		page = 0
-	}
-	if page < 0 {
-		page = 0
+	sr := fdc.SimilarRequest{FdcID: f.FdcID, Metric: c.Query("metric"), DataSource: c.Query("source"), Vector: f.NutrientVector}
+	if sr.Metric == "" {
+		sr.Metric = fdc.COSINE
 	}
-	offset := page * max
-
-	results, err := search(fdc.SearchRequest{Query: q, IndexName: cs.CouchDb.Fts, Max: max, Page: offset})
-	if err != nil {
-		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Search query failed %v", err)})
+	if sr.Metric != fdc.COSINE && sr.Metric != fdc.EUCLIDEAN {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Unrecognized metric parameter.  Must be '%s' or '%s'", fdc.COSINE, fdc.EUCLIDEAN)})
 		return
 	}
-	c.JSON(http.StatusOK, results)
-}
-
-// foodsSearch runs a SearchRequest as a POST and returns a BrowseResult
-func foodsSearchPost(c *gin.Context) {
-	var sr fdc.SearchRequest
-	// check for a query
-	err := c.BindJSON(&sr)
-	if err != nil {
-		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Invalid JSON in request: %v", err)})
+	if err := dataSource(sr.DataSource); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
 		return
 	}
-	if sr.Query == "" {
-		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "Search query is required."})
-		return
+	if c.Query("sameGroup") == "true" && f.Group != nil {
+		sr.FoodGroup = f.Group.Description
 	}
-	if sr.Max == 0 {
-		sr.Max = defaultListMax
-	} else if sr.Max > maxListSize || sr.Max < 0 {
-		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("max parameter %d must be > 0 or <=  %d", sr.Max, maxListSize)})
+	max, err := strconv.Atoi(c.Query("max"))
+	if err != nil {
+		max = defaultListMax
+	}
+	if max <= 0 || max > maxListSize {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("max parameter %d must be > 0 and <= %d", max, maxListSize)})
 		return
 	}
-	if sr.Page < 0 {
-		sr.Page = 0
+	sr.Max = max
+	lower, higher := c.QueryArray("lower"), c.QueryArray("higher")
+	if c.Query("healthier") == "true" && len(lower) == 0 && len(higher) == 0 {
+		for _, n := range similar.Healthier {
+			lower = append(lower, strconv.Itoa(n))
+		}
 	}
-	// only run REGEX searches against a keyword index
-	if sr.SearchType == fdc.REGEX {
-		sr.SearchField += "_kw"
+	if sr.Lower, err = vectorPositions(lower); err == nil {
+		sr.Higher, err = vectorPositions(higher)
 	}
-	sr.Page = sr.Page * sr.Max
-	sr.IndexName = cs.CouchDb.Fts
-	results, err := search(sr)
 	if err != nil {
-		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Search query failed %v", err)})
-		return
-	}
-	c.JSON(http.StatusOK, results)
-}
-
-// returns openapi spec in either json or yaml format
-func specDoc(c *gin.Context) {
-	t := c.Param("type")
-	if t == "" || (t != "yaml" && t != "json") {
-		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "a doc type is required: yaml or json"})
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
 		return
 	}
-	if t == "yaml" {
-		raw, err := ioutil.ReadFile(YAMLSPEC)
+	if len(sr.Vector) == 0 {
+		nd, err := foodNutrients(f.FdcID)
 		if err != nil {
-			log.Println(err.Error())
-			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "Cannot retrieve YAML doc"})
+			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Query error %v", err)})
 			return
 		}
-		c.Data(http.StatusOK, gin.MIMEYAML, raw)
-	} else {
-		raw, err := ioutil.ReadFile(JSONSPEC)
-		if err != nil {
-			log.Println(err.Error())
-			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "Cannot retrieve JSON doc"})
+		if sr.Vector, err = foodVector(f, nd); err != nil {
+			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
 			return
 		}
-		c.Data(http.StatusOK, gin.MIMEJSON, raw)
 	}
-	return
-}
-
-// search performs a SearchRequest on a datastore search and returns the result
-func search(sr fdc.SearchRequest) (fdc.BrowseResult, error) {
-	var (
-		r   []interface{}
-		err error
-	)
-	count := 0
-	if count, err = dc.Search(sr, &r); err != nil {
-		return fdc.BrowseResult{}, err
+	if err := dc.Similar(cs.CouchDb.Bucket, sr, &sf); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Query error %v", err)})
+		return
 	}
-	results := fdc.BrowseResult{Count: int32(count), Start: int32(sr.Page), Max: int32(sr.Max), Items: r}
-	return results, nil
+	c.JSON(http.StatusOK, fdc.SimilarResult{Request: sr, Description: f.Description, Nutrients: similar.Nutrients, Items: sf})
 }
 
-// nutrientReportPost produces a report of nutrient values and returns a BrowseResult
-func nutrientReportPost(c *gin.Context) {
+// foodSubstitutes recommends foods in the same category as a food, with similar nutrient profiles,
+// which meet each of a list of goals, e.g. less sodium and more fiber, ranked by their similarity
+// and how much they improve on the goals
+func foodSubstitutes(c *gin.Context) {
 	var (
-		nutdata []interface{}
-		nr      fdc.NutrientReportRequest
+		f     fdc.Food
+		sf    []fdc.SimilarFood
+		cands []substitute.Candidate
 	)
-	// check for a query
-	err = c.BindJSON(&nr)
-	if err != nil {
-		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err})
+	id := c.Param("id")
+	if len(id) > 7 && isUpc.MatchString(id) {
+		id, _ = upcTofdcid(id, cs.CouchDb.Bucket)
+	}
+	if err := foodDoc(id, &f); err != nil {
+		errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": fmt.Sprintf("No food found for %s", id)})
 		return
 	}
-	if nr.Max <= 0 {
-		nr.Max = defaultListMax
-	} else if nr.Max > maxListSize || nr.Max < 0 {
-		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("max parameter %d must be > 0 or <=  %d", nr.Max, maxListSize)})
+	names := c.QueryArray("goal")
+	if len(names) == 0 {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "At least one goal parameter is required"})
 		return
 	}
-	if &nr.Page == nil {
-		nr.Page = 0
+	goals, err := substitute.ParseGoals(names)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
 	}
-	if nr.Page < 0 {
-		nr.Page = 0
+	max, err := strconv.Atoi(c.Query("max"))
+	if err != nil {
+		max = defaultSubstitutes
 	}
-	if nr.Sort != "" {
-		if strings.ToLower(nr.Sort) != "portion" && strings.ToLower(nr.Sort) != "100value" {
-			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "Value sort values are 'portion' and '100value'"})
+	if max <= 0 || max > maxListSize {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("max parameter %d must be > 0 and <= %d", max, maxListSize)})
+		return
+	}
+	nd, err := foodNutrients(f.FdcID)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Query error %v", err)})
+		return
+	}
+	sr := fdc.SimilarRequest{FdcID: f.FdcID, Metric: fdc.COSINE, Vector: f.NutrientVector, Max: maxListSize}
+	if len(sr.Vector) == 0 {
+		if sr.Vector, err = foodVector(f, nd); err != nil {
+			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
 			return
 		}
 	}
-	if nr.Order != "" {
-		if strings.ToLower(nr.Order) != "asc" && strings.ToLower(nr.Order) != "desc" {
-			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "Order value should be 'asc' and 'desc'"})
-			return
+	if f.Group != nil {
+		sr.FoodGroup = f.Group.Description
+	}
+	// goals on nutrients in the vector are met by the datastore
+	for _, g := range goals {
+		if i, ok := similar.Position(g.Nutrient); ok {
+			if g.Less {
+				sr.Lower = append(sr.Lower, i)
+			} else {
+				sr.Higher = append(sr.Higher, i)
+			}
 		}
-	} else {
-		nr.Order = "desc"
 	}
-	// validate values
-	if nr.ValueLTE < 0 || nr.ValueGTE < 0 {
-		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "ValueGTE  and ValueLTE must be greater than or equal to 0"})
+	if err := dc.Similar(cs.CouchDb.Bucket, sr, &sf); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Query error %v", err)})
 		return
-	} else if nr.ValueGTE == 0 && nr.ValueLTE == 0 {
-		nr.ValueGTE = 0
-		nr.ValueLTE = 100000
-	} else if nr.ValueGTE > nr.ValueLTE {
-		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("ValueGTE %f must be greater than or equal to ValueLTE  %f", nr.ValueGTE, nr.ValueLTE)})
+	}
+	meta := map[int]fdc.NutrientData{}
+	for _, n := range nd {
+		meta[n.Nutrientno] = n
+	}
+	nutrients := []int{fdc.ENERGY}
+	var goalNames []string
+	for _, g := range goals {
+		nutrients = append(nutrients, g.Nutrient)
+		goalNames = append(goalNames, g.Name)
+	}
+	values, err := foodsNutrientValues(sf, nutrients)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Query error %v", err)})
 		return
 	}
-	nr.Page = nr.Page * nr.Max
-
-	if err = dc.NutrientReport(cs.CouchDb.Bucket, nr, &nutdata); err != nil {
-		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Data error %v", err)})
+	for _, s := range sf {
+		cands = append(cands, substitute.Candidate{Food: s, Values: values[s.FdcID]})
+	}
+	subs, err := substitute.Rank(fdc.NewNutrientValues(nd), cands, goals, meta)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
 		return
 	}
-	results := fdc.BrowseNutrientReport{Request: nr, Items: nutdata}
-	c.JSON(http.StatusOK, results)
+	if len(subs) > max {
+		subs = subs[:max]
+	}
+	c.JSON(http.StatusOK, fdc.SubstituteResult{FdcID: f.FdcID, Description: f.Description, Category: sr.FoodGroup, Goals: goalNames, Items: subs})
 }
 
-// Add a user
-func userAdd(c *gin.Context) {
+// dietOptimize solves for the amounts of a pool of candidate foods which meet nutrient targets with
+// the lowest energy or cost.  Targets default to the reference intakes of the life stage in the age,
+// sex, pregnant and lactating parameters and are overridden by targets in the request.
+func dietOptimize(c *gin.Context) {
 	var (
-		rt auth.RoleType
-		dt fdc.DocType
+		or    fdc.OptimizeRequest
+		foods []optimize.Food
 	)
-	u := auth.User{}
-	err := c.BindJSON(&u)
+	if err := c.BindJSON(&or); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Invalid JSON in request: %v", err)})
+		return
+	}
+	if or.Objective == "" {
+		or.Objective = optimize.ENERGY
+	}
+	if or.MaxWeight == 0 {
+		or.MaxWeight = defaultMaxWeight
+	}
+	if or.MaxFoods == 0 {
+		or.MaxFoods = defaultOptimizeFoods
+	}
+	if or.MaxWeight < 0 || or.MaxFoods < 0 || or.MaxFoods > maxOptimizeFoods {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("maxWeight must be > 0 and maxFoods > 0 and <= %d", maxOptimizeFoods)})
+		return
+	}
+	if err := dataSource(or.DataSource); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	targets, err := optimizeTargets(c, or.Targets)
 	if err != nil {
-		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err})
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
 		return
 	}
-	u.Password, err = auth.HashPassword(u.Password)
+	pool, err := optimizePool(or)
 	if err != nil {
-		log.Println(err)
-		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err})
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
 		return
 	}
-	if u.Role == "" {
-		u.Role = rt.ToString(auth.USER)
+	if len(pool) == 0 || len(pool) > maxOptimizeFoods {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Between 1 and %d candidate foods are required", maxOptimizeFoods)})
+		return
 	}
-	u.ID = fmt.Sprintf("%s:%s", dt.ToString(fdc.USER), u.Name)
-	u.Type = dt.ToString(fdc.USER)
-	err = dc.Update(u.ID, u)
+	for _, of := range pool {
+		var f fdc.Food
+		if err := foodDoc(of.FdcID, &f); err != nil {
+			errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": fmt.Sprintf("No food found for %s", of.FdcID)})
+			return
+		}
+		nd, err := foodNutrients(of.FdcID)
+		if err != nil {
+			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Query error %v", err)})
+			return
+		}
+		max := or.MaxWeight
+		if of.Max != nil {
+			max = *of.Max
+		}
+		if of.Min < 0 || max < of.Min {
+			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("%s must have a min >= 0 and <= it's max", of.FdcID)})
+			return
+		}
+		food := optimize.Food{Food: f, Nutrients: nd, Min: of.Min, Max: max, Cost: of.Cost}
+		for _, sv := range f.Servings {
+			if sv.Weight > 0 {
+				food.Portion, food.ServingWeight = sv.Description, float64(sv.Weight)
+				break
+			}
+		}
+		foods = append(foods, food)
+	}
+	r, err := optimize.Optimize(foods, targets, or.Objective, or.WholeServings)
 	if err != nil {
-		log.Println(err)
-		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err})
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
 		return
-
 	}
-	u.Password = ""
-	c.JSON(http.StatusOK, u)
+	c.JSON(http.StatusOK, r)
 }
 
-// Delete a user
-func userDelete(c *gin.Context) {
+// foodPortions lists a food's portions with the household measure each is described in.  An
+// optional measure parameter, e.g. "1 cup", or amount and unit parameters are converted to grams.
+func foodPortions(c *gin.Context) {
 	var (
-		dt fdc.DocType
+		f   fdc.Food
+		err error
+	)
+	q := c.Param("id")
+	if len(q) > 7 {
+		q, _ = upcTofdcid(q, cs.CouchDb.Bucket)
+	}
+	if err = foodDoc(q, &f); err != nil {
+		errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": fmt.Sprintf("No food found for %s", q)})
+		return
+	}
+	var (
+		amount float64
+		unit   string
 	)
+	if m := c.Query("measure"); m != "" {
+		amount, unit, err = units.ParseMeasure(m)
+	} else {
+		amount, unit, err = amountParams(c)
+	}
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	fp := fdc.FoodPortions{FdcID: f.FdcID, Description: f.Description, Portions: units.Portions(f.Servings)}
+	if d, ok := units.Density(f.Servings); ok {
+		fp.Density = &d
+	}
+	if amount > 0 {
+		pc, err := units.Convert(amount, unit, f.Servings)
+		if err != nil {
+			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+			return
+		}
+		fp.Conversion = &pc
+	}
+	c.JSON(http.StatusOK, fp)
+}
 
+// foodInputs expands an FNDDS food into it's input foods, recursively for composite inputs, with
+// each input's contribution to 100 g of the food.  An optional n parameter limits the nutrients.
+func foodInputs(c *gin.Context) {
+	var (
+		f         fdc.Food
+		nutrients []int
+	)
 	id := c.Param("id")
-	if id == "" {
-		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "User name is required"})
+	if len(id) > 7 && isUpc.MatchString(id) {
+		id, _ = upcTofdcid(id, cs.CouchDb.Bucket)
+	}
+	if err := foodDoc(id, &f); err != nil {
+		errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": fmt.Sprintf("No food found for %s", id)})
 		return
 	}
-	uid := fmt.Sprintf("%s:%s", dt.ToString(fdc.USER), id)
-	err = dc.Remove(uid)
+	for _, n := range c.QueryArray("n") {
+		i, err := strconv.Atoi(n)
+		if err != nil {
+			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Invalid nutrient number %s", n)})
+			return
+		}
+		nutrients = append(nutrients, i)
+	}
+	nd, err := foodNutrients(f.FdcID)
 	if err != nil {
-		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "User name name not found"})
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Query error %v", err)})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "message": fmt.Sprintf("User %s deleted ", id)})
+	fb, err := fndds.Expand(f, nd, inputFood, nutrients)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("%s: %v", id, err)})
+		return
+	}
+	c.JSON(http.StatusOK, fb)
 }
 
-// userList returns one user or a list of users
-func userList(c *gin.Context) {
+// foodsCompare returns a table of the nutrients of 2 or more foods, identified by fdcId or UPC in the id
+// parameter, normalized to per 100 g, per serving or per 100 kcal.  An optional n parameter limits
+// the nutrients compared.
+func foodsCompare(c *gin.Context) {
+	var foods []compare.Food
+	ids := getFdcIDs(c.QueryArray("id"))
+	if len(ids) < 2 || len(ids) > maxCompare {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Between 2 and %d id parameters are required", maxCompare)})
+		return
+	}
+	basis := c.Query("basis")
+	if basis == "" {
+		basis = compare.PER100G
+	}
+	if err := compare.ValidBasis(basis); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	var nutrients []int
+	for _, n := range c.QueryArray("n") {
+		i, err := strconv.Atoi(n)
+		if err != nil {
+			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Invalid nutrient number %s", n)})
+			return
+		}
+		nutrients = append(nutrients, i)
+	}
+	for _, id := range ids {
+		var f fdc.Food
+		if err := foodDoc(id, &f); err != nil {
+			errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": fmt.Sprintf("No food found for %s", id)})
+			return
+		}
+		nd, err := foodNutrients(id)
+		if err != nil {
+			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Query error %v", err)})
+			return
+		}
+		portion, w, _ := servingSize(f, 0, "")
+		foods = append(foods, compare.Food{Food: f, Nutrients: nd, Portion: portion, ServingWeight: w})
+	}
+	cmp, err := compare.Compare(foods, basis, nutrients)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, cmp)
+}
+
+// foodsSearch runs a simple keyword search and returns a BrowseResult
+func foodsSearchGet(c *gin.Context) {
 	var (
-		dt fdc.DocType
-		u  auth.User
+		max, page int
+		err       error
 	)
-	q := c.Param("id")
-	if q != "" {
-		uid := fmt.Sprintf("%s:%s", dt.ToString(fdc.USER), q)
-		if err := dc.Get(uid, &u); err != nil {
-			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "User name name not found"})
+	// check for a query
+	q := c.Query("q")
+	if q == "" {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "A search string in the q parameter is required"})
+		return
+	}
+	if max, err = strconv.Atoi(c.Query("max")); err != nil {
+		max = defaultListMax
+	}
+	if max > maxListSize {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("max parameter %d exceeds maximum allowed size of %d", max, maxListSize)})
+		return
+	}
+	if page, err = strconv.Atoi(c.Query("page")); err != nil {
+		page = 0
+	}
+	if page < 0 {
+		page = 0
+	}
+	offset := page * max
+	sort, order, err := searchSort(c.Query("sort"), c.Query("order"))
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+
+	source := c.Query("source")
+	if err = dataSource(source); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+
+	allergens := listParam(c, "excludeAllergens")
+	if err = ingredient.ValidAllergens(allergens); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	diets := listParam(c, "diet")
+	if err = diet.Valid(diets); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+
+	results, err := search(fdc.SearchRequest{Query: q, IndexName: cs.CouchDb.Fts, Max: max, Page: offset, Sort: sort, Order: order, DataSource: source, ExcludeAllergens: allergens, FirstIngredient: c.Query("firstIngredient"), Ingredient: c.Query("ingredient"), Diets: diets})
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Search query failed %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+// foodsSearch runs a SearchRequest as a POST and returns a BrowseResult
+func foodsSearchPost(c *gin.Context) {
+	var sr fdc.SearchRequest
+	// check for a query
+	err := c.BindJSON(&sr)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Invalid JSON in request: %v", err)})
+		return
+	}
+	if sr.Query == "" {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "Search query is required."})
+		return
+	}
+	if sr.Max == 0 {
+		sr.Max = defaultListMax
+	} else if sr.Max > maxListSize || sr.Max < 0 {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("max parameter %d must be > 0 or <=  %d", sr.Max, maxListSize)})
+		return
+	}
+	if sr.Page < 0 {
+		sr.Page = 0
+	}
+	if sr.Sort, sr.Order, err = searchSort(sr.Sort, sr.Order); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	if err = dataSource(sr.DataSource); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	if err = nutrientFilters(sr.Nutrients); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	if err = ingredient.ValidAllergens(sr.ExcludeAllergens); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	if err = diet.Valid(sr.Diets); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	// only run REGEX searches against a keyword index
+	if sr.SearchType == fdc.REGEX {
+		sr.SearchField += "_kw"
+	}
+	sr.Page = sr.Page * sr.Max
+	sr.IndexName = cs.CouchDb.Fts
+	results, err := search(sr)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Search query failed %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+// returns openapi spec in either json or yaml format
+func specDoc(c *gin.Context) {
+	t := c.Param("type")
+	if t == "" || (t != "yaml" && t != "json") {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "a doc type is required: yaml or json"})
+		return
+	}
+	if t == "yaml" {
+		raw, err := ioutil.ReadFile(YAMLSPEC)
+		if err != nil {
+			log.Println(err.Error())
+			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "Cannot retrieve YAML doc"})
 			return
 		}
-		c.JSON(http.StatusOK, u)
+		c.Data(http.StatusOK, gin.MIMEYAML, raw)
 	} else {
-		items, err := dc.GetDictionary(cs.CouchDb.Bucket, dt.ToString(fdc.USER), 0, 100)
+		raw, err := ioutil.ReadFile(JSONSPEC)
 		if err != nil {
-			errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": "Error."})
+			log.Println(err.Error())
+			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "Cannot retrieve JSON doc"})
 			return
 		}
-		results := fdc.BrowseResult{Count: int32(len(items)), Start: int32(0), Max: int32(len(items)), Items: items}
-		c.JSON(http.StatusOK, results)
+		c.Data(http.StatusOK, gin.MIMEJSON, raw)
 	}
+	return
 }
 
-// errorout
-func errorout(c *gin.Context, status int, data gin.H) {
-	switch c.Request.Header.Get("Accept") {
-	case "application/xml":
-		c.XML(status, data)
-	default:
-		c.JSON(status, data)
+// search performs a SearchRequest on a datastore search and returns the result
+func search(sr fdc.SearchRequest) (fdc.BrowseResult, error) {
+	var (
+		hits  []fdc.SearchHit
+		items []interface{}
+		err   error
+	)
+	count := 0
+	if count, err = dc.Search(sr, &hits); err != nil {
+		return fdc.BrowseResult{}, err
 	}
+	for _, h := range hits {
+		items = append(items, h)
+	}
+	results := fdc.BrowseResult{Count: int32(count), Start: int32(sr.Page), Max: int32(sr.Max), Items: items}
+	return results, nil
 }
-func sourceFilter(s string) string {
-	w := ""
-	if s != "" {
-		if s == "BFPD" {
-			w = fmt.Sprintf(" AND ( dataSource = '%s' OR dataSource='%s' )", "LI", "GDSN")
-		} else {
-			w = fmt.Sprintf(" AND dataSource = '%s'", s)
+
+// nutrientReportPost produces a report of nutrient values and returns a BrowseResult
+func nutrientReportPost(c *gin.Context) {
+	var (
+		nutdata []interface{}
+		nr      fdc.NutrientReportRequest
+	)
+	// check for a query
+	err = c.BindJSON(&nr)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err})
+		return
+	}
+	if nr.Max <= 0 {
+		nr.Max = defaultListMax
+	} else if nr.Max > maxListSize || nr.Max < 0 {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("max parameter %d must be > 0 or <=  %d", nr.Max, maxListSize)})
+		return
+	}
+	if &nr.Page == nil {
+		nr.Page = 0
+	}
+	if nr.Page < 0 {
+		nr.Page = 0
+	}
+	if nr.Sort != "" {
+		_, score := fdc.ScoreSorts[nr.Sort]
+		if strings.ToLower(nr.Sort) != "portion" && strings.ToLower(nr.Sort) != "100value" && !score {
+			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Value sort values are 'portion', '100value', '%s', '%s' and '%s'", fdc.NUTRISCORE, fdc.HEALTHSTAR, fdc.NRF)})
+			return
+		}
+		if score && nr.Mode != "" {
+			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "Density, ratio and percent of energy reports are sorted by their metric"})
+			return
 		}
 	}
-	return w
-}
-func sortOrder(o string) (string, error) {
-	order := o
-	if order == "" {
-		order = "asc"
+	if nr.Order != "" {
+		if strings.ToLower(nr.Order) != "asc" && strings.ToLower(nr.Order) != "desc" {
+			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "Order value should be 'asc' and 'desc'"})
+			return
+		}
+	} else {
+		nr.Order = "desc"
 	}
-	if order != "asc" && order != "desc" {
-		return "", errors.New("Unrecognized order parameter.  Must be 'asc' or 'desc'")
+	// validate values
+	if len(nr.Nutrients) > 0 && nr.Mode != "" {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "A mode cannot be combined with a list of nutrients"})
+		return
 	}
-	return order, nil
+	if len(nr.Nutrients) > 0 {
+		if err = nutrientFilters(nr.Nutrients); err != nil {
+			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+			return
+		}
+		if nr.SortNutrient, err = sortNutrient(nr.SortNutrient, nr.Nutrients); err != nil {
+			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+			return
+		}
+	} else if nr.Nutrient <= 0 {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "A nutrientno or a list of nutrients is required"})
+		return
+	} else if err = reportMode(nr); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	} else if nr.ValueLTE < 0 || nr.ValueGTE < 0 {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "ValueGTE  and ValueLTE must be greater than or equal to 0"})
+		return
+	} else if nr.ValueGTE == 0 && nr.ValueLTE == 0 {
+		nr.ValueGTE = 0
+		nr.ValueLTE = 100000
+	} else if nr.ValueGTE > nr.ValueLTE {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("ValueGTE %f must be greater than or equal to ValueLTE  %f", nr.ValueGTE, nr.ValueLTE)})
+		return
+	}
+	if len(nr.Exclude) > 0 {
+		if nr.Derivations, err = provenance.Filter(nr.Exclude); err != nil {
+			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+			return
+		}
+	}
+	nr.Page = nr.Page * nr.Max
+
+	if err = dc.NutrientReport(cs.CouchDb.Bucket, nr, &nutdata); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Data error %v", err)})
+		return
+	}
+	results := fdc.BrowseNutrientReport{Request: nr, Items: nutdata}
+	c.JSON(http.StatusOK, results)
+}
+
+// nutrientStats returns the distribution of a nutrient's values per 100 units across foods optionally
+// filtered by food group and data source.  If a value is provided then it's percentile rank is included.
+func nutrientStats(c *gin.Context) {
+	var (
+		dt     fdc.DocType
+		nd     []interface{}
+		values []float64
+	)
+	sr := fdc.NutrientStatsRequest{FoodGroup: c.Query("fg"), DataSource: c.Query("source")}
+	if sr.Nutrient, err = strconv.Atoi(c.Param("nutrientno")); err != nil || sr.Nutrient <= 0 {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "A valid nutrient number is required"})
+		return
+	}
+	if err = dataSource(sr.DataSource); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	if sr.Bins, err = strconv.Atoi(c.Query("bins")); err != nil {
+		sr.Bins = defaultStatsBins
+	}
+	if sr.Bins <= 0 || sr.Bins > maxStatsBins {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("bins parameter %d must be > 0 and <= %d", sr.Bins, maxStatsBins)})
+		return
+	}
+	if v := c.Query("value"); v != "" {
+		f, err := parseFinite(v)
+		if err != nil {
+			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "value parameter must be a number"})
+			return
+		}
+		sr.Value = &f
+	}
+	if err = dc.NutrientValues(cs.CouchDb.Bucket, sr, &values); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Data error %v", err)})
+		return
+	}
+	if len(values) == 0 {
+		errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": "No values found!"})
+		return
+	}
+	stats := fdc.NutrientStats{Request: sr}
+	q := fmt.Sprintf("SELECT nutrientName,unit from %s WHERE type=\"%s\" AND nutrientNumber=%d LIMIT 1", cs.CouchDb.Bucket, dt.ToString(fdc.NUTDATA), sr.Nutrient)
+	dc.Query(q, &nd)
+	if len(nd) > 0 {
+		b, _ := json.Marshal(nd[0])
+		json.Unmarshal(b, &stats)
+	}
+	stats.Calculate(values)
+	c.JSON(http.StatusOK, stats)
+}
+
+// intakeReferences returns the Daily Values and Dietary Reference Intakes for a life stage
+func intakeReferences(c *gin.Context) {
+	ls, err := lifeStage(c)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	if ls == nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "age and sex parameters are required"})
+		return
+	}
+	refs, err := dri.References(*ls)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	var items []interface{}
+	for _, r := range refs {
+		items = append(items, r)
+	}
+	results := fdc.BrowseResult{Count: int32(len(items)), Start: 0, Max: int32(len(items)), Items: items}
+	c.JSON(http.StatusOK, results)
+}
+
+// intakeFoods compares the nutrients in an amount of one or more foods identified by fdcId or UPC
+// to the Daily Values and, if a life stage is given, to it's RDA, AI and UL.  Each food's amount is
+// it's first serving unless an amount and unit are requested.
+func intakeFoods(c *gin.Context) {
+	var (
+		f   fdc.Food
+		ids []string
+	)
+	if id := c.Param("id"); id != "" {
+		ids = getFdcIDs([]string{id})
+	} else {
+		ids = getFdcIDs(c.QueryArray("id"))
+	}
+	if len(ids) == 0 {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "At least one food id is required"})
+		return
+	}
+	if len(ids) > 24 {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "Cannot request more than 24 id's"})
+		return
+	}
+	ls, err := lifeStage(c)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	amount, unit, err := amountParams(c)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	report := fdc.IntakeReport{}
+	if ls != nil {
+		report.LifeStage = ls.Group()
+	}
+	total := fdc.NutrientValues{}
+	for _, id := range ids {
+		f = fdc.Food{}
+		if err = foodDoc(id, &f); err != nil {
+			errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": fmt.Sprintf("No food found for %s!", id)})
+			return
+		}
+		nd, err := foodNutrients(f.FdcID)
+		if err != nil {
+			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Data error %v", err)})
+			return
+		}
+		size, weight, err := servingSize(f, amount, unit)
+		if err != nil {
+			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("%s: %v", f.FdcID, err)})
+			return
+		}
+		values := fdc.NewNutrientValues(nd).Scale(weight)
+		total.Add(values)
+		intakes, err := dri.Assess(values, ls)
+		if err != nil {
+			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+			return
+		}
+		report.Foods = append(report.Foods, fdc.FoodIntake{FdcID: f.FdcID, Description: f.Description, ServingSize: size, Weight: weight, Intakes: intakes})
+	}
+	if len(report.Foods) > 1 {
+		report.Total, _ = dri.Assess(total, ls)
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// mealPlanAdd generates and saves a meal plan for the current user
+func mealPlanAdd(c *gin.Context) {
+	var mp fdc.MealPlan
+	u, ok := auth.CurrentUser(c)
+	if !ok {
+		errorout(c, http.StatusUnauthorized, gin.H{"status": http.StatusUnauthorized, "message": "Unknown user"})
+		return
+	}
+	if err := c.BindJSON(&mp); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Invalid JSON in request: %v", err)})
+		return
+	}
+	if mp.Days == 0 {
+		mp.Days = defaultMealPlanDays
+	}
+	if mp.MealsPerDay == 0 {
+		mp.MealsPerDay = defaultMealsPerDay
+	}
+	if mp.Days < 0 || mp.Days > maxMealPlanDays || mp.MealsPerDay < 0 || mp.MealsPerDay > maxMealsPerDay {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("days must be > 0 and <= %d and mealsPerDay > 0 and <= %d", maxMealPlanDays, maxMealsPerDay)})
+		return
+	}
+	if err := ingredient.ValidAllergens(mp.ExcludeAllergens); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	if err := diet.Valid(mp.Diets); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	items, err := mealPlanItems(u.Name, mp.MealPlanRequest)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	if mp.Plan, err = mealplan.Generate(items, mp.Days, mp.MealsPerDay, mp.Targets, rand.New(rand.NewSource(time.Now().UnixNano()))); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	mp.ID = strconv.FormatInt(time.Now().UnixNano(), 36)
+	mp.Owner = u.Name
+	if err := mealPlanSave(&mp); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, mp)
+}
+
+// mealPlanDay regenerates one day of one of the current user's meal plans from the plan's recipes,
+// foods and targets
+func mealPlanDay(c *gin.Context) {
+	var mp fdc.MealPlan
+	u, ok := auth.CurrentUser(c)
+	if !ok {
+		errorout(c, http.StatusUnauthorized, gin.H{"status": http.StatusUnauthorized, "message": "Unknown user"})
+		return
+	}
+	id, ok := ownedID(c, "Meal plan")
+	if !ok {
+		return
+	}
+	if err := dc.Get(mealPlanKey(u.Name, id), &mp); err != nil {
+		errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": fmt.Sprintf("Meal plan %s not found", id)})
+		return
+	}
+	day, err := strconv.Atoi(c.Param("day"))
+	if err != nil || day < 1 || day > len(mp.Plan) {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("day must be a number from 1 to %d", len(mp.Plan))})
+		return
+	}
+	items, err := mealPlanItems(u.Name, mp.MealPlanRequest)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	previous := fdc.MealPlanDay{Day: day - 1}
+	if day > 1 {
+		previous = mp.Plan[day-2]
+	}
+	if mp.Plan[day-1], err = mealplan.Day(items, mp.MealsPerDay, mp.Targets, previous, rand.New(rand.NewSource(time.Now().UnixNano()))); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	if err := mealPlanSave(&mp); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, mp)
+}
+
+// mealPlanGet returns one of the current user's meal plans
+func mealPlanGet(c *gin.Context) {
+	var mp fdc.MealPlan
+	u, ok := auth.CurrentUser(c)
+	if !ok {
+		errorout(c, http.StatusUnauthorized, gin.H{"status": http.StatusUnauthorized, "message": "Unknown user"})
+		return
+	}
+	id, ok := ownedID(c, "Meal plan")
+	if !ok {
+		return
+	}
+	if err := dc.Get(mealPlanKey(u.Name, id), &mp); err != nil {
+		errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": fmt.Sprintf("Meal plan %s not found", id)})
+		return
+	}
+	c.JSON(http.StatusOK, mp)
+}
+
+// mealPlanDelete removes one of the current user's meal plans
+func mealPlanDelete(c *gin.Context) {
+	u, ok := auth.CurrentUser(c)
+	if !ok {
+		errorout(c, http.StatusUnauthorized, gin.H{"status": http.StatusUnauthorized, "message": "Unknown user"})
+		return
+	}
+	id, ok := ownedID(c, "Meal plan")
+	if !ok {
+		return
+	}
+	if err := dc.Remove(mealPlanKey(u.Name, id)); err != nil {
+		errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": fmt.Sprintf("Meal plan %s not found", id)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "message": fmt.Sprintf("Meal plan %s deleted ", id)})
+}
+
+// mealPlanList returns a page of the current user's meal plans ordered by name
+func mealPlanList(c *gin.Context) {
+	var (
+		max, page int64
+		dt        fdc.DocType
+		items     []interface{}
+	)
+	u, ok := auth.CurrentUser(c)
+	if !ok {
+		errorout(c, http.StatusUnauthorized, gin.H{"status": http.StatusUnauthorized, "message": "Unknown user"})
+		return
+	}
+	if max, err = strconv.ParseInt(c.Query("max"), 10, 32); err != nil {
+		max = defaultListMax
+	}
+	if max > maxListSize {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("max parameter %d exceeds maximum allowed size of %d", max, maxListSize)})
+		return
+	}
+	if page, err = strconv.ParseInt(c.Query("page"), 10, 32); err != nil || page < 0 {
+		page = 0
+	}
+	q := fmt.Sprintf("SELECT m.* FROM %s AS m WHERE type=\"%s\" AND owner=$owner ORDER BY name OFFSET %d LIMIT %d", cs.CouchDb.Bucket, dt.ToString(fdc.MEALPLAN), page*max, max)
+	if err := dc.QueryParams(q, map[string]interface{}{"owner": u.Name}, &items); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Query error %v", err)})
+		return
+	}
+	results := fdc.BrowseResult{Count: int32(len(items)), Start: int32(page), Max: int32(max), Items: items}
+	c.JSON(http.StatusOK, results)
+}
+
+// diaryAdd logs a food in the current user's diary
+func diaryAdd(c *gin.Context) {
+	var e fdc.DiaryEntry
+	u, ok := auth.CurrentUser(c)
+	if !ok {
+		errorout(c, http.StatusUnauthorized, gin.H{"status": http.StatusUnauthorized, "message": "Unknown user"})
+		return
+	}
+	if err := c.BindJSON(&e); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Invalid JSON in request: %v", err)})
+		return
+	}
+	e.ID = strconv.FormatInt(time.Now().UnixNano(), 36)
+	e.Owner = u.Name
+	if err := diarySave(&e); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, e)
+}
+
+// diaryUpdate replaces one of the current user's diary entries
+func diaryUpdate(c *gin.Context) {
+	var e, old fdc.DiaryEntry
+	u, ok := auth.CurrentUser(c)
+	if !ok {
+		errorout(c, http.StatusUnauthorized, gin.H{"status": http.StatusUnauthorized, "message": "Unknown user"})
+		return
+	}
+	id, ok := ownedID(c, "Diary entry")
+	if !ok {
+		return
+	}
+	if err := dc.Get(diaryKey(u.Name, id), &old); err != nil {
+		errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": fmt.Sprintf("Diary entry %s not found", id)})
+		return
+	}
+	if err := c.BindJSON(&e); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Invalid JSON in request: %v", err)})
+		return
+	}
+	e.ID = id
+	e.Owner = u.Name
+	if e.Timestamp.IsZero() {
+		e.Timestamp = old.Timestamp
+	}
+	if err := diarySave(&e); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, e)
+}
+
+// diaryGet returns one of the current user's diary entries
+func diaryGet(c *gin.Context) {
+	var e fdc.DiaryEntry
+	u, ok := auth.CurrentUser(c)
+	if !ok {
+		errorout(c, http.StatusUnauthorized, gin.H{"status": http.StatusUnauthorized, "message": "Unknown user"})
+		return
+	}
+	id, ok := ownedID(c, "Diary entry")
+	if !ok {
+		return
+	}
+	if err := dc.Get(diaryKey(u.Name, id), &e); err != nil {
+		errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": fmt.Sprintf("Diary entry %s not found", id)})
+		return
+	}
+	c.JSON(http.StatusOK, e)
+}
+
+// diaryDelete removes one of the current user's diary entries
+func diaryDelete(c *gin.Context) {
+	u, ok := auth.CurrentUser(c)
+	if !ok {
+		errorout(c, http.StatusUnauthorized, gin.H{"status": http.StatusUnauthorized, "message": "Unknown user"})
+		return
+	}
+	id, ok := ownedID(c, "Diary entry")
+	if !ok {
+		return
+	}
+	if err := dc.Remove(diaryKey(u.Name, id)); err != nil {
+		errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": fmt.Sprintf("Diary entry %s not found", id)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "message": fmt.Sprintf("Diary entry %s deleted ", id)})
+}
+
+// diaryList returns the current user's diary entries from one date to another, default today
+func diaryList(c *gin.Context) {
+	u, ok := auth.CurrentUser(c)
+	if !ok {
+		errorout(c, http.StatusUnauthorized, gin.H{"status": http.StatusUnauthorized, "message": "Unknown user"})
+		return
+	}
+	from, to, err := diaryRange(c, 1)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	entries, err := diaryEntries(u.Name, from, to)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Query error %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"from": from, "to": to, "count": len(entries), "items": entries})
+}
+
+// diaryTotals returns the current user's nutrient totals, daily averages and percent Daily Values
+// by day or week from one date to another, default the last 7 days
+func diaryTotals(c *gin.Context) {
+	u, ok := auth.CurrentUser(c)
+	if !ok {
+		errorout(c, http.StatusUnauthorized, gin.H{"status": http.StatusUnauthorized, "message": "Unknown user"})
+		return
+	}
+	from, to, err := diaryRange(c, 7)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	period := c.Query("period")
+	if period == "" {
+		period = diary.DAY
+	}
+	entries, err := diaryEntries(u.Name, from, to)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Query error %v", err)})
+		return
+	}
+	totals, err := diary.Totals(entries, period)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"from": from, "to": to, "period": period, "items": totals})
+}
+
+// diaryTrends returns the current user's daily totals of nutrients in the n parameter, default
+// energy, with their moving averages and slopes from one date to another, default the last 30 days
+func diaryTrends(c *gin.Context) {
+	var nutrients []int
+	u, ok := auth.CurrentUser(c)
+	if !ok {
+		errorout(c, http.StatusUnauthorized, gin.H{"status": http.StatusUnauthorized, "message": "Unknown user"})
+		return
+	}
+	from, to, err := diaryRange(c, 30)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	for _, n := range c.QueryArray("n") {
+		i, err := strconv.Atoi(n)
+		if err != nil {
+			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Invalid nutrient number %s", n)})
+			return
+		}
+		nutrients = append(nutrients, i)
+	}
+	if len(nutrients) == 0 {
+		nutrients = []int{fdc.ENERGY}
+	}
+	entries, err := diaryEntries(u.Name, from, to)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Query error %v", err)})
+		return
+	}
+	trends, err := diary.Trends(entries, nutrients)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"from": from, "to": to, "items": trends})
+}
+
+// recipeAnalyze sums the nutrients of a recipe's ingredients and returns them per recipe, per serving
+// and per 100 grams of the cooked recipe
+func recipeAnalyze(c *gin.Context) {
+	var r fdc.RecipeRequest
+	if err := c.BindJSON(&r); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Invalid JSON in request: %v", err)})
+		return
+	}
+	foods, err := recipeFoods(&r)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	ra, err := recipe.Analyze(r, foods)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ra)
+}
+
+// ingredientsIndex parses the ingredient statements of a page of foods and saves the parsed lists on
+// the foods so they can be browsed and searched by ingredient
+func ingredientsIndex(c *gin.Context) {
+	indexFoods(c, " AND ingredients IS VALUED", "ingredientList", func(f fdc.Food) (interface{}, error) {
+		return ingredient.ParseStatement(f.Ingredients), nil
+	})
+}
+
+// dietsIndex classifies a page of foods and saves the classifications on the foods so they can be
+// browsed and searched by diet
+func dietsIndex(c *gin.Context) {
+	indexFoods(c, "", "diets", func(f fdc.Food) (interface{}, error) {
+		nd, err := foodNutrients(f.FdcID)
+		if err != nil {
+			return nil, err
+		}
+		return foodDiets(f, nd), nil
+	})
+}
+
+// scoresIndex scores a page of foods and saves the scores on the foods so they can be used to sort
+// browse and nutrient reports
+func scoresIndex(c *gin.Context) {
+	indexFoods(c, "", "scores", func(f fdc.Food) (interface{}, error) {
+		nd, err := foodNutrients(f.FdcID)
+		if err != nil {
+			return nil, err
+		}
+		return foodScores(f, nd), nil
+	})
+}
+
+// qualityFood checks a food's nutrient values and servings against the data quality rules
+func qualityFood(c *gin.Context) {
+	var f fdc.Food
+	tolerance, err := qualityTolerance(c)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	id := c.Param("id")
+	if len(id) > 7 && isUpc.MatchString(id) {
+		id, _ = upcTofdcid(id, cs.CouchDb.Bucket)
+	}
+	if err := foodDoc(id, &f); err != nil {
+		errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": fmt.Sprintf("No food found for %s", id)})
+		return
+	}
+	nd, err := foodNutrients(id)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Query error %v", err)})
+		return
+	}
+	r := quality.Check(f, nd, tolerance)
+	r.Type = ""
+	r.UpdatedAt = time.Now()
+	c.JSON(http.StatusOK, r)
+}
+
+// qualityIndex checks a page of foods against the data quality rules and saves a QUALITY document
+// for each food with findings.  Documents of foods which now pass are removed.
+func qualityIndex(c *gin.Context) {
+	tolerance, err := qualityTolerance(c)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	pageFoods(c, "", func(id string, f fdc.Food) error {
+		nd, err := foodNutrients(id)
+		if err != nil {
+			return err
+		}
+		r := quality.Check(f, nd, tolerance)
+		if len(r.Findings) == 0 {
+			dc.Remove(qualityKey(id))
+			return nil
+		}
+		r.UpdatedAt = time.Now()
+		return dc.Update(qualityKey(id), r)
+	})
+}
+
+// qualityBrowse returns a page of the saved data quality reports, optionally restricted to a data
+// source and to foods failing a rule
+func qualityBrowse(c *gin.Context) {
+	var (
+		max, page int64
+		dt        fdc.DocType
+		items     []interface{}
+	)
+	source := c.Query("source")
+	if err = dataSource(source); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	if max, err = strconv.ParseInt(c.Query("max"), 10, 32); err != nil {
+		max = defaultListMax
+	}
+	if max > maxListSize {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("max parameter %d exceeds maximum allowed size of %d", max, maxListSize)})
+		return
+	}
+	if page, err = strconv.ParseInt(c.Query("page"), 10, 32); err != nil || page < 0 {
+		page = 0
+	}
+	where := sourceFilter(source)
+	if rule := c.Query("rule"); rule != "" {
+		valid := false
+		for _, r := range quality.Rules() {
+			valid = valid || r == rule
+		}
+		if !valid {
+			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Unrecognized rule %s.  Must be one of %s", rule, strings.Join(quality.Rules(), ", "))})
+			return
+		}
+		where += fmt.Sprintf(" AND ANY f IN findings SATISFIES f.rule = \"%s\" END", rule)
+	}
+	q := fmt.Sprintf("SELECT q.* FROM %s AS q WHERE type=\"%s\" %s ORDER BY fdcId OFFSET %d LIMIT %d", cs.CouchDb.Bucket, dt.ToString(fdc.QUALITY), where, page*max, max)
+	if err := dc.Query(q, &items); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Query error %v", err)})
+		return
+	}
+	results := fdc.BrowseResult{Count: int32(len(items)), Start: int32(page), Max: int32(max), Items: items}
+	c.JSON(http.StatusOK, results)
+}
+
+// vectorsIndex saves the nutrient vectors of a page of foods used to find similar foods
+func vectorsIndex(c *gin.Context) {
+	indexFoods(c, "", "nutrientVector", func(f fdc.Food) (interface{}, error) {
+		nd, err := foodNutrients(f.FdcID)
+		if err != nil {
+			return nil, err
+		}
+		if v, ok := similar.Vector(fdc.NewNutrientValues(nd)); ok {
+			return v, nil
+		}
+		return nil, nil
+	})
+}
+
+// indexFoods saves a value computed from each food in one page of a data source's foods, default
+// BFPD, in a field of the food.  Each call does a single page; callers request the next page until
+// a page returns a count of 0.
+func indexFoods(c *gin.Context, where string, path string, value func(f fdc.Food) (interface{}, error)) {
+	pageFoods(c, where, func(id string, f fdc.Food) error {
+		v, err := value(f)
+		if err != nil {
+			return err
+		}
+		return dc.UpdateField(id, path, v)
+	})
+}
+
+// pageFoods runs a job on each of a page of a data source's foods, default BFPD, and reports the
+// number of foods done and the ids of those which failed
+func pageFoods(c *gin.Context, where string, job func(id string, f fdc.Food) error) {
+	var (
+		max, page int64
+		dt        fdc.DocType
+		r         []interface{}
+	)
+	source := c.Query("source")
+	if source == "" {
+		source = dt.ToString(fdc.BFPD)
+	}
+	if err = dataSource(source); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	if max, err = strconv.ParseInt(c.Query("max"), 10, 32); err != nil {
+		max = defaultIndexBatch
+	}
+	if max <= 0 || max > maxIndexBatch {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("max parameter %d must be > 0 or <= %d", max, maxIndexBatch)})
+		return
+	}
+	if page, err = strconv.ParseInt(c.Query("page"), 10, 32); err != nil || page < 0 {
+		page = 0
+	}
+	q := fmt.Sprintf("SELECT RAW fdcId FROM %s WHERE type=\"%s\" %s%s ORDER BY fdcId OFFSET %d LIMIT %d", cs.CouchDb.Bucket, dt.ToString(fdc.FOOD), sourceFilter(source), where, page*max, max)
+	if err = dc.Query(q, &r); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Query error %v", err)})
+		return
+	}
+	var failed []string
+	for _, i := range r {
+		var f fdc.Food
+		id := fmt.Sprintf("%v", i)
+		err := dc.Get(id, &f)
+		if err == nil {
+			err = job(id, f)
+		}
+		if err != nil {
+			log.Printf("%s: %v\n", id, err)
+			failed = append(failed, id)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "page": page, "count": len(r) - len(failed), "failed": failed})
+}
+
+// recipeAdd saves a new recipe for the current user with it's nutrients
+func recipeAdd(c *gin.Context) {
+	var r fdc.Recipe
+	u, ok := auth.CurrentUser(c)
+	if !ok {
+		errorout(c, http.StatusUnauthorized, gin.H{"status": http.StatusUnauthorized, "message": "Unknown user"})
+		return
+	}
+	if err := c.BindJSON(&r); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Invalid JSON in request: %v", err)})
+		return
+	}
+	r.ID = strconv.FormatInt(time.Now().UnixNano(), 36)
+	r.Owner = u.Name
+	if err := recipeSave(&r); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+// recipeUpdate replaces one of the current user's recipes and recomputes it's nutrients
+func recipeUpdate(c *gin.Context) {
+	var r, old fdc.Recipe
+	u, ok := auth.CurrentUser(c)
+	if !ok {
+		errorout(c, http.StatusUnauthorized, gin.H{"status": http.StatusUnauthorized, "message": "Unknown user"})
+		return
+	}
+	id, ok := ownedID(c, "Recipe")
+	if !ok {
+		return
+	}
+	if err := dc.Get(recipeKey(u.Name, id), &old); err != nil {
+		errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": fmt.Sprintf("Recipe %s not found", id)})
+		return
+	}
+	if err := c.BindJSON(&r); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Invalid JSON in request: %v", err)})
+		return
+	}
+	r.ID = id
+	r.Owner = u.Name
+	if err := recipeSave(&r); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+// recipeGet returns one of the current user's recipes
+func recipeGet(c *gin.Context) {
+	var r fdc.Recipe
+	u, ok := auth.CurrentUser(c)
+	if !ok {
+		errorout(c, http.StatusUnauthorized, gin.H{"status": http.StatusUnauthorized, "message": "Unknown user"})
+		return
+	}
+	id, ok := ownedID(c, "Recipe")
+	if !ok {
+		return
+	}
+	if err := dc.Get(recipeKey(u.Name, id), &r); err != nil {
+		errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": fmt.Sprintf("Recipe %s not found", id)})
+		return
+	}
+	rs := []fdc.Recipe{r}
+	if err := recipesRefresh(rs); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rs[0])
+}
+
+// recipeDelete removes one of the current user's recipes
+func recipeDelete(c *gin.Context) {
+	u, ok := auth.CurrentUser(c)
+	if !ok {
+		errorout(c, http.StatusUnauthorized, gin.H{"status": http.StatusUnauthorized, "message": "Unknown user"})
+		return
+	}
+	id, ok := ownedID(c, "Recipe")
+	if !ok {
+		return
+	}
+	if err := dc.Remove(recipeKey(u.Name, id)); err != nil {
+		errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": fmt.Sprintf("Recipe %s not found", id)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "message": fmt.Sprintf("Recipe %s deleted ", id)})
+}
+
+// recipeList returns a page of the current user's recipes ordered by name
+func recipeList(c *gin.Context) {
+	var (
+		max, page int64
+		dt        fdc.DocType
+		items     []interface{}
+	)
+	u, ok := auth.CurrentUser(c)
+	if !ok {
+		errorout(c, http.StatusUnauthorized, gin.H{"status": http.StatusUnauthorized, "message": "Unknown user"})
+		return
+	}
+	if max, err = strconv.ParseInt(c.Query("max"), 10, 32); err != nil {
+		max = defaultListMax
+	}
+	if max > maxListSize {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("max parameter %d exceeds maximum allowed size of %d", max, maxListSize)})
+		return
+	}
+	if page, err = strconv.ParseInt(c.Query("page"), 10, 32); err != nil || page < 0 {
+		page = 0
+	}
+	q := fmt.Sprintf("SELECT r.* FROM %s AS r WHERE type=\"%s\" AND owner=$owner ORDER BY name OFFSET %d LIMIT %d", cs.CouchDb.Bucket, dt.ToString(fdc.RECIPE), page*max, max)
+	if err := dc.QueryParams(q, map[string]interface{}{"owner": u.Name}, &items); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Query error %v", err)})
+		return
+	}
+	var rs []fdc.Recipe
+	b, err := json.Marshal(items)
+	if err == nil {
+		err = json.Unmarshal(b, &rs)
+	}
+	if err == nil {
+		err = recipesRefresh(rs)
+	}
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	items = nil
+	for _, r := range rs {
+		items = append(items, r)
+	}
+	results := fdc.BrowseResult{Count: int32(len(items)), Start: int32(page), Max: int32(max), Items: items}
+	c.JSON(http.StatusOK, results)
+}
+
+// recipeRecompute recalculates the cached nutrients of every saved recipe using one of the requested
+// foods.  It's run after a food's NUTDATA documents are reloaded.
+func recipeRecompute(c *gin.Context) {
+	var (
+		dt    fdc.DocType
+		items []interface{}
+		rs    []fdc.Recipe
+	)
+	ids := getFdcIDs(c.QueryArray("id"))
+	if len(ids) == 0 {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "At least one food id is required"})
+		return
+	}
+	qids, err := buildIDList(ids)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	q := fmt.Sprintf("SELECT r.* FROM %s AS r WHERE type=\"%s\" AND ANY f IN foods SATISFIES f IN %s END", cs.CouchDb.Bucket, dt.ToString(fdc.RECIPE), qids)
+	if err := dc.Query(q, &items); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Query error %v", err)})
+		return
+	}
+	b, err := json.Marshal(items)
+	if err == nil {
+		err = json.Unmarshal(b, &rs)
+	}
+	if err != nil {
+		errorout(c, http.StatusInternalServerError, gin.H{"status": http.StatusInternalServerError, "message": err.Error()})
+		return
+	}
+	var failed []string
+	for i := range rs {
+		if err := recipeSave(&rs[i]); err != nil {
+			log.Printf("recipe %s: %v\n", recipeKey(rs[i].Owner, rs[i].ID), err)
+			failed = append(failed, recipeKey(rs[i].Owner, rs[i].ID))
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "recipes": len(rs) - len(failed), "failed": failed})
+}
+
+// ingredientsParse parses free-text ingredient lines and matches each line's food phrase against the
+// search index to propose candidate foods ranked by confidence
+func ingredientsParse(c *gin.Context) {
+	var ir fdc.IngredientParseRequest
+	if err := c.BindJSON(&ir); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Invalid JSON in request: %v", err)})
+		return
+	}
+	if len(ir.Lines) == 0 || len(ir.Lines) > maxIngredients {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Between 1 and %d lines are required", maxIngredients)})
+		return
+	}
+	if ir.Max == 0 {
+		ir.Max = defaultCandidates
+	} else if ir.Max < 0 || ir.Max > maxCandidates {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("max parameter %d must be > 0 or <= %d", ir.Max, maxCandidates)})
+		return
+	}
+	if err := dataSource(ir.DataSource); err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err.Error()})
+		return
+	}
+	var lines []fdc.IngredientLine
+	for _, l := range ir.Lines {
+		il := ingredient.ParseLine(l)
+		if il.Food != "" {
+			ics, err := ingredientCandidates(il, ir)
+			if err != nil {
+				errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": fmt.Sprintf("Search query failed %v", err)})
+				return
+			}
+			il.Candidates = ics
+		}
+		lines = append(lines, il)
+	}
+	c.JSON(http.StatusOK, lines)
+}
+
+// Add a user
+func userAdd(c *gin.Context) {
+	var (
+		rt auth.RoleType
+		dt fdc.DocType
+	)
+	u := auth.User{}
+	err := c.BindJSON(&u)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err})
+		return
+	}
+	u.Password, err = auth.HashPassword(u.Password)
+	if err != nil {
+		log.Println(err)
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err})
+		return
+	}
+	if u.Role == "" {
+		u.Role = rt.ToString(auth.USER)
+	}
+	u.ID = fmt.Sprintf("%s:%s", dt.ToString(fdc.USER), u.Name)
+	u.Type = dt.ToString(fdc.USER)
+	err = dc.Update(u.ID, u)
+	if err != nil {
+		log.Println(err)
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": err})
+		return
+
+	}
+	u.Password = ""
+	c.JSON(http.StatusOK, u)
+}
+
+// Delete a user
+func userDelete(c *gin.Context) {
+	var (
+		dt fdc.DocType
+	)
+
+	id := c.Param("id")
+	if id == "" {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "User name is required"})
+		return
+	}
+	uid := fmt.Sprintf("%s:%s", dt.ToString(fdc.USER), id)
+	err = dc.Remove(uid)
+	if err != nil {
+		errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "User name name not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "message": fmt.Sprintf("User %s deleted ", id)})
+}
+
+// userList returns one user or a list of users
+func userList(c *gin.Context) {
+	var (
+		dt fdc.DocType
+		u  auth.User
+	)
+	q := c.Param("id")
+	if q != "" {
+		uid := fmt.Sprintf("%s:%s", dt.ToString(fdc.USER), q)
+		if err := dc.Get(uid, &u); err != nil {
+			errorout(c, http.StatusBadRequest, gin.H{"status": http.StatusBadRequest, "message": "User name name not found"})
+			return
+		}
+		c.JSON(http.StatusOK, u)
+	} else {
+		items, err := dc.GetDictionary(cs.CouchDb.Bucket, dt.ToString(fdc.USER), 0, 100)
+		if err != nil {
+			errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": "Error."})
+			return
+		}
+		results := fdc.BrowseResult{Count: int32(len(items)), Start: int32(0), Max: int32(len(items)), Items: items}
+		c.JSON(http.StatusOK, results)
+	}
+}
+
+// errorout
+func errorout(c *gin.Context, status int, data gin.H) {
+	switch c.Request.Header.Get("Accept") {
+	case "application/xml":
+		c.XML(status, data)
+	default:
+		c.JSON(status, data)
+	}
+}
+func sourceFilter(s string) string {
+	var dt fdc.DocType
+	w := ""
+	if s != "" {
+		var ors []string
+		for _, src := range dt.ToDataSources(s) {
+			ors = append(ors, fmt.Sprintf("dataSource = '%s'", src))
+		}
+		w = fmt.Sprintf(" AND ( %s )", strings.Join(ors, " OR "))
+	}
+	return w
+}
+
+// returns the values of a comma separated list parameter
+func listParam(c *gin.Context, name string) []string {
+	var a []string
+	for _, n := range strings.Split(c.Query(name), ",") {
+		if n = strings.TrimSpace(n); n != "" {
+			a = append(a, n)
+		}
+	}
+	return a
+}
+func sortOrder(o string) (string, error) {
+	order := o
+	if order == "" {
+		order = "asc"
+	}
+	if order != "asc" && order != "desc" {
+		return "", errors.New("Unrecognized order parameter.  Must be 'asc' or 'desc'")
+	}
+	return order, nil
+}
+
+// validates a search sort and order.  Relevance is the default sort and is
+// ordered highest score first unless asked otherwise
+func searchSort(s string, o string) (string, string, error) {
+	sort := s
+	if sort == "" {
+		sort = fdc.RELEVANCE
+	}
+	if sort != fdc.RELEVANCE && sort != fdc.DESCRIPTION && sort != fdc.COMPANY && sort != fdc.PUBLICATIONDATE {
+		return "", "", fmt.Errorf("Unrecognized sort parameter.  Must be '%s', '%s', '%s' or '%s'", fdc.RELEVANCE, fdc.DESCRIPTION, fdc.COMPANY, fdc.PUBLICATIONDATE)
+	}
+	if sort == fdc.RELEVANCE && o == "" {
+		return sort, "desc", nil
+	}
+	order, err := sortOrder(o)
+	return sort, order, err
+}
+
+// validates a data source filter which must be one of SR, FNDDS or BFPD if present
+func dataSource(s string) error {
+	var dt fdc.DocType
+	if s != "" && s != dt.ToString(fdc.SR) && s != dt.ToString(fdc.FNDDS) && s != dt.ToString(fdc.BFPD) {
+		return fmt.Errorf("Unrecognized data source.  Must be %s, %s or %s", dt.ToString(fdc.BFPD), dt.ToString(fdc.SR), dt.ToString(fdc.FNDDS))
+	}
+	return nil
+}
+
+// validates a list of nutrient value constraints
+func nutrientFilters(nf []fdc.NutrientFilter) error {
+	if len(nf) > maxNutrientFilters {
+		return fmt.Errorf("Cannot filter on more than %d nutrients", maxNutrientFilters)
+	}
+	for _, n := range nf {
+		if n.Nutrient <= 0 {
+			return errors.New("A nutrientno is required for each nutrient filter")
+		}
+		if n.ValueGTE == nil && n.ValueLTE == nil {
+			return fmt.Errorf("Nutrient %d requires a valueGTE and/or valueLTE", n.Nutrient)
+		}
+		if (n.ValueGTE != nil && *n.ValueGTE < 0) || (n.ValueLTE != nil && *n.ValueLTE < 0) {
+			return fmt.Errorf("Nutrient %d valueGTE and valueLTE must be greater than or equal to 0", n.Nutrient)
+		}
+		if n.ValueGTE != nil && n.ValueLTE != nil && *n.ValueGTE > *n.ValueLTE {
+			return fmt.Errorf("Nutrient %d valueGTE %f must be less than or equal to valueLTE %f", n.Nutrient, *n.ValueGTE, *n.ValueLTE)
+		}
+	}
+	return nil
+}
+
+// validates a nutrient report mode and the nutrients it needs
+func reportMode(nr fdc.NutrientReportRequest) error {
+	switch nr.Mode {
+	case "":
+		return nil
+	case fdc.DENSITY:
+		if nr.Nutrient == fdc.ENERGY {
+			return fmt.Errorf("Cannot report the density of nutrient %d", fdc.ENERGY)
+		}
+	case fdc.RATIO:
+		if nr.Denominator <= 0 || nr.Denominator == nr.Nutrient {
+			return errors.New("A ratio report requires a denominatorNutrientno different from the nutrientno")
+		}
+	case fdc.ENERGYPERCENT:
+		if _, ok := fdc.AtwaterFactors[nr.Nutrient]; !ok {
+			return fmt.Errorf("Percent of energy is reported only for nutrients %d, %d, %d and %d", fdc.PROTEIN, fdc.TOTALFAT, fdc.CARBOHYDRATE, fdc.ALCOHOL)
+		}
+	default:
+		return fmt.Errorf("Unrecognized mode.  Must be '%s', '%s' or '%s'", fdc.DENSITY, fdc.RATIO, fdc.ENERGYPERCENT)
+	}
+	if strings.ToLower(nr.Sort) == "portion" {
+		return fmt.Errorf("A %s report cannot be sorted by portion", nr.Mode)
+	}
+	return nil
+}
+
+// returns the nutrient to sort a report on a list of nutrients, which defaults to the first
+// one in the list.  Also makes sure a nutrient isn't constrained more than once.
+func sortNutrient(sn int, nf []fdc.NutrientFilter) (int, error) {
+	found := false
+	seen := map[int]bool{}
+	for _, n := range nf {
+		if seen[n.Nutrient] {
+			return 0, fmt.Errorf("Nutrient %d is listed more than once", n.Nutrient)
+		}
+		seen[n.Nutrient] = true
+		found = found || n.Nutrient == sn
+	}
+	if sn == 0 {
+		return nf[0].Nutrient, nil
+	}
+	if !found {
+		return 0, fmt.Errorf("Sort nutrient %d must be one of the report's nutrients", sn)
+	}
+	return sn, nil
+}
+
+// parses a number parameter, rejecting NaN and infinities which strconv accepts
+func parseFinite(v string) (float64, error) {
+	f, err := strconv.ParseFloat(v, 64)
+	if err == nil && (math.IsNaN(f) || math.IsInf(f, 0)) {
+		err = fmt.Errorf("%s is not a finite number", v)
+	}
+	return f, err
+}
+
+// returns the amount and unit parameters used to scale nutrient values.  An amount defaults
+// to grams and a unit to an amount of 1.  A 0 amount is returned if neither was requested.
+func amountParams(c *gin.Context) (float64, string, error) {
+	a, unit := c.Query("amount"), c.Query("unit")
+	if a == "" && unit == "" {
+		return 0, "", nil
+	}
+	amount := 1.0
+	if a != "" {
+		var err error
+		if amount, err = units.ParseAmount(a); err != nil || amount <= 0 {
+			return 0, "", errors.New("amount parameter must be a number greater than 0")
+		}
+	}
+	if unit == "" {
+		unit = "g"
+	}
+	return amount, unit, nil
+}
+
+// returns the NUTDATA fields selected by the food nutrient endpoints in addition to their value
+// and unit.  The derivation is always returned and the samples and their range with provenance.
+func provenanceFields(prov bool) string {
+	if prov {
+		return ",derivation,datapoints,`min`,`max`"
+	}
+	return ",derivation"
+}
+
+// returns the provenance of a NUTDATA query row
+func nutrientProvenance(b []byte) *fdc.Provenance {
+	var nd fdc.NutrientData
+	json.Unmarshal(b, &nd)
+	return provenance.Of(nd)
+}
+
+// scales a food's nutrient values per 100 units to an amount of the food in a unit of mass,
+// volume or one of the food's serving descriptions
+func scaleNutrients(nfb *fdc.NutrientFoodBrowse, amount float64, unit string) error {
+	var f fdc.Food
+	if err := dc.Get(nfb.FdcID, &f); err != nil {
+		return err
+	}
+	g, err := units.Grams(amount, unit, f.Servings)
+	if err != nil {
+		return err
+	}
+	nfb.Amount = amount
+	nfb.AmountUnit = unit
+	nfb.AmountWeight = g
+	for i := range nfb.Nutrients {
+		v := nfb.Nutrients[i].Value * g / 100
+		nfb.Nutrients[i].AmountValue = &v
+	}
+	return nil
+}
+
+// returns the life stage described by the sex, age, pregnant and lactating parameters
+// or nil if no age or sex was requested
+func lifeStage(c *gin.Context) (*dri.LifeStage, error) {
+	a, sex := c.Query("age"), c.Query("sex")
+	if a == "" && sex == "" {
+		return nil, nil
+	}
+	age, err := parseFinite(a)
+	if err != nil {
+		return nil, errors.New("age parameter must be a number of years")
+	}
+	ls := dri.LifeStage{Sex: strings.ToLower(sex), Age: age, Pregnant: c.Query("pregnant") == "true", Lactating: c.Query("lactating") == "true"}
+	if err = ls.Validate(); err != nil {
+		return nil, err
+	}
+	return &ls, nil
+}
+
+// validates a recipe's ingredients, converts any UPC's to fdcId's and returns the ingredients' foods
+// and nutrient data keyed by fdcId
+func recipeFoods(r *fdc.RecipeRequest) (map[string]recipe.Food, error) {
+	if len(r.Ingredients) == 0 || len(r.Ingredients) > maxIngredients {
+		return nil, fmt.Errorf("A recipe must have between 1 and %d ingredients", maxIngredients)
+	}
+	foods := map[string]recipe.Food{}
+	for i := range r.Ingredients {
+		in := &r.Ingredients[i]
+		if in.FdcID == "" && in.Upc != "" {
+			in.FdcID, _ = upcTofdcid(in.Upc, cs.CouchDb.Bucket)
+		}
+		if in.FdcID == "" {
+			return nil, fmt.Errorf("Ingredient %d requires a valid fdcId or upc", i+1)
+		}
+		if in.Amount <= 0 || in.Unit == "" {
+			return nil, fmt.Errorf("Ingredient %s requires an amount greater than 0 and a unit", in.FdcID)
+		}
+		if _, ok := foods[in.FdcID]; ok {
+			continue
+		}
+		var f fdc.Food
+		if err := foodDoc(in.FdcID, &f); err != nil {
+			return nil, fmt.Errorf("No food found for ingredient %s", in.FdcID)
+		}
+		nd, err := foodNutrients(in.FdcID)
+		if err != nil {
+			return nil, err
+		}
+		foods[in.FdcID] = recipe.Food{Food: f, Nutrients: nd}
+	}
+	return foods, nil
+}
+
+// returns a food's nutrient vector
+func foodVector(f fdc.Food, nd []fdc.NutrientData) ([]float64, error) {
+	v, ok := similar.Vector(fdc.NewNutrientValues(nd))
+	if !ok {
+		return nil, fmt.Errorf("Food %s has no energy value to build a nutrient vector", f.FdcID)
+	}
+	return v, nil
+}
+
+// returns the values per 100 g of a list of nutrients for a list of foods keyed by fdcId
+func foodsNutrientValues(foods []fdc.SimilarFood, nutrients []int) (map[string]fdc.NutrientValues, error) {
+	var (
+		dt   fdc.DocType
+		r    []interface{}
+		ids  []string
+		nids []string
+	)
+	values := map[string]fdc.NutrientValues{}
+	if len(foods) == 0 {
+		return values, nil
+	}
+	for _, f := range foods {
+		ids = append(ids, fmt.Sprintf("\"%s\"", f.FdcID))
+	}
+	for _, n := range nutrients {
+		nids = append(nids, strconv.Itoa(n))
+	}
+	q := fmt.Sprintf("SELECT fdcId,nutrientNumber,valuePer100UnitServing from %s WHERE type=\"%s\" AND fdcId IN [%s] AND nutrientNumber IN [%s]", cs.CouchDb.Bucket, dt.ToString(fdc.NUTDATA), strings.Join(ids, ","), strings.Join(nids, ","))
+	if err := dc.Query(q, &r); err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	var nd []fdc.NutrientData
+	if err = json.Unmarshal(b, &nd); err != nil {
+		return nil, err
+	}
+	for _, n := range nd {
+		if values[n.FdcID] == nil {
+			values[n.FdcID] = fdc.NutrientValues{}
+		}
+		values[n.FdcID][n.Nutrientno] = n.Value
+	}
+	return values, nil
+}
+
+// returns the nutrient targets of a diet optimization: those of the life stage in the request
+// parameters, if any, replaced by the targets in the request
+func optimizeTargets(c *gin.Context, requested []fdc.NutrientTarget) ([]fdc.NutrientTarget, error) {
+	var targets []fdc.NutrientTarget
+	ls, err := lifeStage(c)
+	if err != nil {
+		return nil, err
+	}
+	if ls != nil {
+		if targets, err = optimize.Targets(*ls); err != nil {
+			return nil, err
+		}
+	}
+	for _, t := range requested {
+		if t.Min == nil && t.Max == nil {
+			return nil, fmt.Errorf("Target for nutrient %d requires a min or max", t.Nutrientno)
+		}
+		replaced := false
+		for i := range targets {
+			if targets[i].Nutrientno == t.Nutrientno {
+				targets[i], replaced = t, true
+			}
+		}
+		if !replaced {
+			targets = append(targets, t)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("Nutrient targets or a life stage are required")
+	}
+	return targets, nil
+}
+
+// returns the candidate foods of a diet optimization: the foods listed in the request followed by
+// up to maxFoods foods from each food group and from a search.  Listed foods keep their bounds and
+// cost.
+func optimizePool(or fdc.OptimizeRequest) ([]fdc.OptimizeFood, error) {
+	var (
+		dt   fdc.DocType
+		pool []fdc.OptimizeFood
+	)
+	seen := map[string]bool{}
+	add := func(of fdc.OptimizeFood) {
+		if of.FdcID != "" && !seen[of.FdcID] {
+			seen[of.FdcID] = true
+			pool = append(pool, of)
+		}
+	}
+	for _, of := range or.Foods {
+		of.FdcID = getFdcIDs([]string{of.FdcID})[0]
+		add(of)
+	}
+	for _, fg := range or.FoodGroups {
+		var r []interface{}
+		q := fmt.Sprintf("SELECT RAW fdcId FROM %s WHERE type=\"%s\" AND foodGroup.description=$fg %s ORDER BY fdcId LIMIT %d", cs.CouchDb.Bucket, dt.ToString(fdc.FOOD), sourceFilter(or.DataSource), or.MaxFoods)
+		if err := dc.QueryParams(q, map[string]interface{}{"fg": fg}, &r); err != nil {
+			return nil, err
+		}
+		for _, id := range r {
+			add(fdc.OptimizeFood{FdcID: fmt.Sprintf("%v", id)})
+		}
+	}
+	if or.Query != "" {
+		var hits []fdc.SearchHit
+		sr := fdc.SearchRequest{Query: or.Query, Max: or.MaxFoods, DataSource: or.DataSource, IndexName: cs.CouchDb.Fts}
+		sr.Sort, sr.Order, _ = searchSort("", "")
+		if _, err := dc.Search(sr, &hits); err != nil {
+			return nil, err
+		}
+		for _, h := range hits {
+			add(fdc.OptimizeFood{FdcID: h.FdcID})
+		}
+	}
+	return pool, nil
+}
+
+// returns the positions in a nutrient vector of a list of nutrient numbers
+func vectorPositions(nutrients []string) ([]int, error) {
+	var p []int
+	for _, n := range nutrients {
+		no, err := strconv.Atoi(n)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid nutrient number %s", n)
+		}
+		i, ok := similar.Position(no)
+		if !ok {
+			return nil, fmt.Errorf("Nutrient %d is not in the nutrient vector.  Must be one of %v", no, similar.Nutrients)
+		}
+		p = append(p, i)
+	}
+	return p, nil
+}
+
+// returns the datastore key of a food's data quality report
+func qualityKey(fdcID string) string {
+	var dt fdc.DocType
+	return fmt.Sprintf("%s:%s", dt.ToString(fdc.QUALITY), fdcID)
+}
+
+// returns the tolerance parameter, the percent reported energy may differ from calculated energy
+func qualityTolerance(c *gin.Context) (float64, error) {
+	if c.Query("tolerance") == "" {
+		return quality.DefaultTolerance, nil
+	}
+	t, err := parseFinite(c.Query("tolerance"))
+	if err != nil || t <= 0 || t > 100 {
+		return 0, fmt.Errorf("tolerance parameter %s must be a percent > 0 and <= 100", c.Query("tolerance"))
+	}
+	return t, nil
+}
+
+// returns a where clause excluding foods with a saved data quality report
+func qualityFilter() string {
+	var dt fdc.DocType
+	return fmt.Sprintf(" AND NOT EXISTS (SELECT RAW 1 FROM %s q USE KEYS \"%s:\" || food.fdcId)", cs.CouchDb.Bucket, dt.ToString(fdc.QUALITY))
+}
+
+// returns the id path parameter of one of the current user's documents and false, after responding
+// not found, when it has a colon.  Keys are TYPE:owner:id so an id with a colon could name the
+// document of another user whose name starts with the current user's.
+func ownedID(c *gin.Context, doc string) (string, bool) {
+	id := c.Param("id")
+	if strings.Contains(id, ":") {
+		errorout(c, http.StatusNotFound, gin.H{"status": http.StatusNotFound, "message": fmt.Sprintf("%s %s not found", doc, id)})
+		return id, false
+	}
+	return id, true
+}
+
+// returns the datastore key of a user's recipe
+func recipeKey(owner string, id string) string {
+	var dt fdc.DocType
+	return fmt.Sprintf("%s:%s:%s", dt.ToString(fdc.RECIPE), owner, id)
+}
+
+// returns the datastore key of a user's meal plan
+func mealPlanKey(owner string, id string) string {
+	var dt fdc.DocType
+	return fmt.Sprintf("%s:%s:%s", dt.ToString(fdc.MEALPLAN), owner, id)
+}
+
+// upserts a meal plan
+func mealPlanSave(mp *fdc.MealPlan) error {
+	var dt fdc.DocType
+	mp.Type = dt.ToString(fdc.MEALPLAN)
+	mp.UpdatedAt = time.Now()
+	return dc.Update(mealPlanKey(mp.Owner, mp.ID), mp)
+}
+
+// returns the recipes and foods a meal plan's meals are drawn from: the owner's recipes listed in
+// the request, or all of them, and the listed foods, less those excluded by allergens or diets.
+// A recipe is excluded if any of it's foods is.
+func mealPlanItems(owner string, mr fdc.MealPlanRequest) ([]mealplan.Item, error) {
+	var (
+		dt      fdc.DocType
+		items   []mealplan.Item
+		recipes []fdc.Recipe
+	)
+	excluded := map[string]bool{}
+	isExcluded := func(id string) (bool, error) {
+		if x, ok := excluded[id]; ok {
+			return x, nil
+		}
+		var f fdc.Food
+		if err := foodDoc(id, &f); err != nil {
+			return false, fmt.Errorf("No food found for %s", id)
+		}
+		x, err := foodExcluded(f, mr.ExcludeAllergens, mr.Diets)
+		excluded[id] = x
+		return x, err
+	}
+	if len(mr.Recipes) == 0 {
+		var r []interface{}
+		q := fmt.Sprintf("SELECT r.* FROM %s AS r WHERE type=\"%s\" AND owner=$owner ORDER BY name LIMIT %d", cs.CouchDb.Bucket, dt.ToString(fdc.RECIPE), maxListSize)
+		if err := dc.QueryParams(q, map[string]interface{}{"owner": owner}, &r); err != nil {
+			return nil, err
+		}
+		b, err := json.Marshal(r)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(b, &recipes); err != nil {
+			return nil, err
+		}
+	}
+	for _, id := range mr.Recipes {
+		var r fdc.Recipe
+		if strings.Contains(id, ":") {
+			return nil, fmt.Errorf("Recipe %s not found", id)
+		}
+		if err := dc.Get(recipeKey(owner, id), &r); err != nil {
+			return nil, fmt.Errorf("Recipe %s not found", id)
+		}
+		recipes = append(recipes, r)
+	}
+	// plan from current nutrient values rather than stale cached analyses
+	if err := recipesRefresh(recipes); err != nil {
+		return nil, err
+	}
+recipes:
+	for _, r := range recipes {
+		for _, id := range r.Foods {
+			x, err := isExcluded(id)
+			if err != nil {
+				return nil, err
+			}
+			if x {
+				continue recipes
+			}
+		}
+		items = append(items, mealplan.Item{Type: mealplan.RECIPE, ID: r.ID, Description: r.Name, Weight: r.Analysis.ServingWeight, Nutrients: r.Analysis.PerServing})
+	}
+	for _, id := range getFdcIDs(mr.Foods) {
+		x, err := isExcluded(id)
+		if err != nil {
+			return nil, err
+		}
+		if x {
+			continue
+		}
+		var f fdc.Food
+		if err := foodDoc(id, &f); err != nil {
+			return nil, fmt.Errorf("No food found for %s", id)
+		}
+		nd, err := foodNutrients(id)
+		if err != nil {
+			return nil, err
+		}
+		portion, w, _ := servingSize(f, 0, "")
+		items = append(items, mealplan.Item{Type: mealplan.FOOD, ID: f.FdcID, Description: f.Description, Portion: portion, Weight: w, Nutrients: weightNutrients(nd, w)})
+	}
+	return items, nil
+}
+
+// returns the nutrients in a weight in grams of a food in nutrient number order
+func weightNutrients(nd []fdc.NutrientData, weight float64) []fdc.RecipeNutrient {
+	var rn []fdc.RecipeNutrient
+	meta := map[int]fdc.NutrientData{}
+	for _, d := range nd {
+		meta[d.Nutrientno] = d
+	}
+	for n, v := range fdc.NewNutrientValues(nd).Scale(weight) {
+		rn = append(rn, fdc.RecipeNutrient{Nutrientno: n, Nutrient: meta[n].Nutrient, Unit: meta[n].Unit, Value: math.Round(v*1000) / 1000})
+	}
+	sort.Slice(rn, func(i, j int) bool { return rn[i].Nutrientno < rn[j].Nutrientno })
+	return rn
+}
+
+// returns the datastore key of a user's diary entry
+func diaryKey(owner string, id string) string {
+	var dt fdc.DocType
+	return fmt.Sprintf("%s:%s:%s", dt.ToString(fdc.DIARY), owner, id)
+}
+
+// computes a diary entry's weight and nutrients from it's food and upserts it.  Entries without a
+// timestamp are logged now.
+func diarySave(e *fdc.DiaryEntry) error {
+	var (
+		dt fdc.DocType
+		f  fdc.Food
+	)
+	if err := diary.ValidMeal(e.Meal); err != nil {
+		return err
+	}
+	if e.FdcID == "" && e.Upc != "" {
+		e.FdcID, _ = upcTofdcid(e.Upc, cs.CouchDb.Bucket)
+	}
+	if e.FdcID == "" {
+		return errors.New("A diary entry requires a valid fdcId or upc")
+	}
+	if e.Amount <= 0 {
+		return errors.New("A diary entry requires an amount greater than 0")
+	}
+	if err := foodDoc(e.FdcID, &f); err != nil {
+		return fmt.Errorf("No food found for %s", e.FdcID)
+	}
+	g, err := units.Grams(e.Amount, e.Unit, f.Servings)
+	if err != nil {
+		return err
+	}
+	nd, err := foodNutrients(e.FdcID)
+	if err != nil {
+		return err
+	}
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+	e.Date = e.Timestamp.Format(diary.DateFormat)
+	e.Description = f.Description
+	e.Weight = math.Round(g*10) / 10
+	e.Nutrients = weightNutrients(nd, g)
+	e.Type = dt.ToString(fdc.DIARY)
+	return dc.Update(diaryKey(e.Owner, e.ID), e)
+}
+
+// returns the from and to dates of a diary request.  To defaults to today and from to days - 1
+// before to.
+func diaryRange(c *gin.Context, days int) (string, string, error) {
+	to := time.Now()
+	if t := c.Query("to"); t != "" {
+		d, err := time.Parse(diary.DateFormat, t)
+		if err != nil {
+			return "", "", fmt.Errorf("to parameter %s must be a date yyyy-mm-dd", t)
+		}
+		to = d
+	}
+	from := to.AddDate(0, 0, 1-days)
+	if f := c.Query("from"); f != "" {
+		d, err := time.Parse(diary.DateFormat, f)
+		if err != nil {
+			return "", "", fmt.Errorf("from parameter %s must be a date yyyy-mm-dd", f)
+		}
+		from = d
+	}
+	if from.After(to) || to.Sub(from).Hours()/24 >= maxDiaryDays {
+		return "", "", fmt.Errorf("from must be on or before to and the range no more than %d days", maxDiaryDays)
+	}
+	return from.Format(diary.DateFormat), to.Format(diary.DateFormat), nil
+}
+
+// returns a user's diary entries between two dates in the order they were eaten
+func diaryEntries(owner string, from string, to string) ([]fdc.DiaryEntry, error) {
+	var (
+		dt      fdc.DocType
+		r       []interface{}
+		entries []fdc.DiaryEntry
+	)
+	q := fmt.Sprintf("SELECT d.* FROM %s AS d WHERE type=\"%s\" AND owner=$owner AND date BETWEEN $from AND $to ORDER BY timestamp", cs.CouchDb.Bucket, dt.ToString(fdc.DIARY))
+	if err := dc.QueryParams(q, map[string]interface{}{"owner": owner, "from": from, "to": to}, &r); err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, &entries)
+	return entries, err
+}
+
+// returns true if a food contains or may contain any of a list of allergens, as browse and search
+// exclude them, or isn't suitable for each of a list of diets
+func foodExcluded(f fdc.Food, allergens []string, diets []string) (bool, error) {
+	text := f.Ingredients
+	if text == "" {
+		text = f.Description
+	}
+	contains, mayContain := ingredient.DetectAllergens(text)
+	for _, m := range append(contains, mayContain...) {
+		for _, a := range allergens {
+			if m.Allergen == a {
+				return true, nil
+			}
+		}
+	}
+	if len(diets) == 0 {
+		return false, nil
+	}
+	if len(f.Diets) == 0 {
+		nd, err := foodNutrients(f.FdcID)
+		if err != nil {
+			return false, err
+		}
+		f.Diets = foodDiets(f, nd)
+	}
+	for _, d := range diets {
+		suitable := false
+		for _, cl := range f.Diets {
+			suitable = suitable || (cl.Diet == d && cl.Status == diet.SUITABLE)
+		}
+		if !suitable {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// analyzes a recipe and upserts it with it's nutrients and list of foods
+func recipeSave(r *fdc.Recipe) error {
+	var dt fdc.DocType
+	foods, err := recipeFoods(&r.RecipeRequest)
+	if err != nil {
+		return err
+	}
+	if r.Analysis, err = recipe.Analyze(r.RecipeRequest, foods); err != nil {
+		return err
+	}
+	r.Foods = nil
+	var nd []fdc.NutrientData
+	for id, f := range foods {
+		r.Foods = append(r.Foods, id)
+		nd = append(nd, f.Nutrients...)
+	}
+	sort.Strings(r.Foods)
+	r.Checksum = recipe.Checksum(nd)
+	r.Type = dt.ToString(fdc.RECIPE)
+	r.UpdatedAt = time.Now()
+	return dc.Update(recipeKey(r.Owner, r.ID), r)
+}
+
+// recomputes and saves any recipes whose cached nutrients were computed from NUTDATA which has
+// since changed.  The NUTDATA values of all of the recipes' foods are fetched in one query.
+func recipesRefresh(rs []fdc.Recipe) error {
+	var (
+		dt  fdc.DocType
+		ids []string
+		r   []interface{}
+		nd  []fdc.NutrientData
+	)
+	for _, rc := range rs {
+		ids = append(ids, rc.Foods...)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	qids, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	q := fmt.Sprintf("SELECT fdcId,nutrientNumber,valuePer100UnitServing FROM %s WHERE type=\"%s\" AND fdcId IN %s", cs.CouchDb.Bucket, dt.ToString(fdc.NUTDATA), qids)
+	if err := dc.Query(q, &r); err != nil {
+		return err
+	}
+	b, err := json.Marshal(r)
+	if err == nil {
+		err = json.Unmarshal(b, &nd)
+	}
+	if err != nil {
+		return err
+	}
+	byFood := map[string][]fdc.NutrientData{}
+	for _, d := range nd {
+		byFood[d.FdcID] = append(byFood[d.FdcID], d)
+	}
+	for i := range rs {
+		var fnd []fdc.NutrientData
+		for _, id := range rs[i].Foods {
+			fnd = append(fnd, byFood[id]...)
+		}
+		if recipe.Checksum(fnd) == rs[i].Checksum {
+			continue
+		}
+		if err := recipeSave(&rs[i]); err != nil {
+			return fmt.Errorf("Recipe %s could not be recomputed: %v", rs[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// searches for the foods matching an ingredient line's food phrase and returns them ordered by
+// confidence with the line's amount in grams of each food.  The servings of all of the matched
+// foods are fetched in one query.
+func ingredientCandidates(il fdc.IngredientLine, ir fdc.IngredientParseRequest) ([]fdc.IngredientCandidate, error) {
+	var (
+		hits  []fdc.SearchHit
+		err   error
+		best  float64
+		ics   []fdc.IngredientCandidate
+		ids   []string
+		r     []interface{}
+		foods []fdc.Food
+	)
+	sr := fdc.SearchRequest{Query: il.Food, Max: ir.Max, DataSource: ir.DataSource, IndexName: cs.CouchDb.Fts}
+	if sr.Sort, sr.Order, err = searchSort("", ""); err != nil {
+		return nil, err
+	}
+	if _, err = dc.Search(sr, &hits); err != nil {
+		return nil, err
+	}
+	if len(hits) == 0 {
+		return ics, nil
+	}
+	for _, h := range hits {
+		ids = append(ids, h.FdcID)
+	}
+	q := fmt.Sprintf("SELECT fdcId,servingSizes FROM %s USE KEYS $ids", cs.CouchDb.Bucket)
+	if err = dc.QueryParams(q, map[string]interface{}{"ids": ids}, &r); err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(r)
+	if err == nil {
+		err = json.Unmarshal(b, &foods)
+	}
+	if err != nil {
+		return nil, err
+	}
+	servings := map[string][]fdc.Serving{}
+	for _, f := range foods {
+		servings[f.FdcID] = f.Servings
+	}
+	for _, h := range hits {
+		if h.Score > best {
+			best = h.Score
+		}
+		ic := fdc.IngredientCandidate{FdcID: h.FdcID, Description: h.Description, Source: h.Source, Score: h.Score}
+		if ss, ok := servings[h.FdcID]; ok {
+			if g, ok := ingredientWeight(il, ss); ok {
+				ic.Weight = &g
+			}
+		}
+		ics = append(ics, ic)
+	}
+	for i := range ics {
+		ics[i].Confidence = ingredient.Confidence(il.Food, ics[i].Description, ics[i].Score, best)
+	}
+	sort.SliceStable(ics, func(i, j int) bool { return ics[i].Confidence > ics[j].Confidence })
+	return ics, nil
+}
+
+// returns the weight in grams of an ingredient line's amount of a food.  Lines without a unit are
+// counted in the food's first serving with a weight, e.g. "2 eggs" as 2 of a "large" serving.
+func ingredientWeight(il fdc.IngredientLine, servings []fdc.Serving) (float64, bool) {
+	unit := il.Unit
+	if unit == "" {
+		for _, s := range servings {
+			if s.Weight > 0 {
+				unit = s.Description
+				break
+			}
+		}
+	}
+	g, err := units.Grams(il.Amount, unit, servings)
+	if err != nil {
+		return 0, false
+	}
+	return math.Round(g*10) / 10, true
+}
+
+// classifies a food's suitability for diets from it's ingredients, food group and nutrient values
+// in it's first serving with a weight
+func foodDiets(f fdc.Food, nd []fdc.NutrientData) []fdc.DietClass {
+	_, w, _ := servingSize(f, 0, "")
+	return diet.Classify(f, fdc.NewNutrientValues(nd), w)
+}
+
+// scores a food from it's nutrient values and an estimate of it's fruits and vegetables from
+// it's food group or ingredients
+func foodScores(f fdc.Food, nd []fdc.NutrientData) *fdc.NutrientScores {
+	items := f.IngredientList
+	if len(items) == 0 && f.Ingredients != "" {
+		items = ingredient.ParseStatement(f.Ingredients)
+	}
+	return score.Scores(fdc.NewNutrientValues(nd), score.FruitVegetables(f, items))
+}
+
+// returns the food and NUTDATA an FNDDS input food's SR code or food code refers to.  SR codes are
+// matched with and without leading zeros.
+func inputFood(srcode int) (fdc.Food, []fdc.NutrientData, bool, error) {
+	var (
+		dt fdc.DocType
+		f  fdc.Food
+		r  []interface{}
+	)
+	q := fmt.Sprintf("SELECT RAW fdcId FROM %s WHERE type=\"%s\" AND ndbno IN [\"%d\",\"%05d\"] LIMIT 1", cs.CouchDb.Bucket, dt.ToString(fdc.FOOD), srcode, srcode)
+	if err := dc.Query(q, &r); err != nil {
+		return f, nil, false, err
+	}
+	if len(r) == 0 {
+		return f, nil, false, nil
+	}
+	if err := dc.Get(fmt.Sprintf("%v", r[0]), &f); err != nil {
+		return f, nil, false, nil
+	}
+	nd, err := foodNutrients(f.FdcID)
+	return f, nd, err == nil, err
+}
+
+// loads the FOOD document with an id taken from a request.  Documents of any other type, e.g. a
+// user's recipe or diary entry, are treated as not found.
+func foodDoc(id string, f *fdc.Food) error {
+	var dt fdc.DocType
+	if err := dc.Get(id, f); err != nil {
+		return err
+	}
+	if f.Type != dt.ToString(fdc.FOOD) {
+		*f = fdc.Food{}
+		return fmt.Errorf("%s is not a food", id)
+	}
+	return nil
+}
+
+// returns all of the NUTDATA documents for a food
+func foodNutrients(fdcID string) ([]fdc.NutrientData, error) {
+	var (
+		dt fdc.DocType
+		r  []interface{}
+		nd []fdc.NutrientData
+	)
+	q := fmt.Sprintf("SELECT nutrient.* from %s as nutrient WHERE type=\"%s\" AND fdcId = \"%s\"", cs.CouchDb.Bucket, dt.ToString(fdc.NUTDATA), fdcID)
+	if err := dc.Query(q, &r); err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, &nd)
+	return nd, err
+}
+
+// returns the label text and weight in grams of a requested amount of a food or, if none
+// was requested, of the food's first serving with a weight or 100 grams
+func servingSize(f fdc.Food, amount float64, unit string) (string, float64, error) {
+	if amount > 0 {
+		g, err := units.Grams(amount, unit, f.Servings)
+		if err != nil {
+			return "", 0, err
+		}
+		return fmt.Sprintf("%s %s (%sg)", strconv.FormatFloat(amount, 'f', -1, 64), unit, strconv.FormatFloat(math.Round(g), 'f', -1, 64)), g, nil
+	}
+	for _, s := range f.Servings {
+		if s.Weight <= 0 {
+			continue
+		}
+		w := strconv.FormatFloat(math.Round(float64(s.Weight)), 'f', -1, 64)
+		if u, ok := units.Lookup(s.Description); ok && u.Kind == units.MASS {
+			return fmt.Sprintf("%s%s", w, u.Name), float64(s.Weight), nil
+		}
+		a := 1.0
+		if s.Servingamount > 0 {
+			a = float64(s.Servingamount)
+		}
+		return fmt.Sprintf("%s %s (%sg)", strconv.FormatFloat(a, 'f', -1, 64), s.Description, w), float64(s.Weight), nil
+	}
+	return "100g", 100, nil
 }
 
 // converts an array of ids to a query string of the form ["12345",23456",...]
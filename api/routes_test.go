@@ -116,3 +116,20 @@ func TestFoodsSearchPostHandler(t *testing.T) {
 		t.Errorf("Expecting %d status is %d message is %s", http.StatusOK, parsed["status"], parsed["message"])
 	}
 }
+
+func TestOwnedID(t *testing.T) {
+	router := gin.New()
+	router.GET("/recipe/:id", func(c *gin.Context) {
+		if id, ok := ownedID(c, "Recipe"); ok {
+			c.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": id})
+		}
+	})
+	for id, status := range map[string]int{"abc-123": http.StatusOK, "b:c": http.StatusNotFound} {
+		req, _ := http.NewRequest("GET", "/recipe/"+id, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		if resp.Code != status {
+			t.Errorf("id %s status is %d SB %d", id, resp.Code, status)
+		}
+	}
+}
@@ -60,10 +60,38 @@ func (r *RoleType) ToString(rt RoleType) string {
 	}
 }
 
-var identityKey = "role"
+var (
+	identityKey = "role"
+	nameKey     = "name"
+)
 
-// AuthMiddleware initializes our jwt components
+// AuthMiddleware initializes our jwt components for routes restricted to users with the ADMIN role
 func (u *User) AuthMiddleware(bucket string, d ds.DataSource) *jwt.GinJWTMiddleware {
+	var rt RoleType
+	return middleware(d, func(v *User) bool {
+		return v.Role == rt.ToString(ADMIN)
+	})
+}
+
+// UserMiddleware initializes our jwt components for routes open to any authenticated user
+func (u *User) UserMiddleware(bucket string, d ds.DataSource) *jwt.GinJWTMiddleware {
+	return middleware(d, func(v *User) bool {
+		return v.Name != ""
+	})
+}
+
+// CurrentUser returns the authenticated user of a request that has passed through one of the jwt middlewares
+func CurrentUser(c *gin.Context) (*User, bool) {
+	v, ok := c.Get(identityKey)
+	if !ok {
+		return nil, false
+	}
+	u, ok := v.(*User)
+	return u, ok
+}
+
+// middleware builds a jwt middleware which admits users for which authorized returns true
+func middleware(d ds.DataSource, authorized func(*User) bool) *jwt.GinJWTMiddleware {
 
 	a, _ := jwt.New(&jwt.GinJWTMiddleware{
 		Realm:       "bfpd zone",
@@ -96,20 +124,22 @@ func (u *User) AuthMiddleware(bucket string, d ds.DataSource) *jwt.GinJWTMiddlew
 				//u, _ = findUser(v.ID, d)
 				return jwt.MapClaims{
 					identityKey: v.Role,
+					nameKey:     v.Name,
 				}
 			}
 			return jwt.MapClaims{}
 		},
 		IdentityHandler: func(c *gin.Context) interface{} {
 			claims := jwt.ExtractClaims(c)
+			// tokens issued before the name claim was added carry only the role
+			name, _ := claims[nameKey].(string)
 			return &User{
+				Name: name,
 				Role: claims[identityKey].(string),
 			}
 		},
-		// must have ADMIN role from the roles assigned to the current user
 		Authorizator: func(data interface{}, c *gin.Context) bool {
-			var rt RoleType
-			if v, ok := data.(*User); ok && v.Role == rt.ToString(ADMIN) {
+			if v, ok := data.(*User); ok && authorized(v) {
 				return true
 			}
 			return false
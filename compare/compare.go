@@ -0,0 +1,118 @@
+// Package compare aligns the nutrient values of foods in a table so they can be compared side by side
+package compare
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+// PER100G etc are the bases a comparison's values are normalized to
+const (
+	PER100G    = "100g"
+	SERVING    = "serving"
+	PER100KCAL = "100kcal"
+)
+
+// Food is a food to compare, it's NUTDATA and the label and weight in grams of it's serving
+type Food struct {
+	Food          fdc.Food
+	Nutrients     []fdc.NutrientData
+	Portion       string
+	ServingWeight float64
+}
+
+// Bases returns the bases a comparison can be normalized to
+func Bases() []string {
+	return []string{PER100G, SERVING, PER100KCAL}
+}
+
+// ValidBasis returns an error if a basis isn't one of the Bases
+func ValidBasis(basis string) error {
+	for _, b := range Bases() {
+		if b == basis {
+			return nil
+		}
+	}
+	return fmt.Errorf("Unrecognized basis %s.  Must be '%s', '%s' or '%s'", basis, PER100G, SERVING, PER100KCAL)
+}
+
+// Compare builds a table of the nutrients of foods normalized to a basis.  Nutrients are limited
+// to a list of nutrient numbers if one is given.  Highest and lowest values are only flagged for
+// nutrients with values for at least two foods which differ.
+func Compare(foods []Food, basis string, only []int) (fdc.Comparison, error) {
+	cmp := fdc.Comparison{Basis: basis}
+	if err := ValidBasis(basis); err != nil {
+		return cmp, err
+	}
+	want := map[int]bool{}
+	for _, n := range only {
+		want[n] = true
+	}
+	rows := map[int]*fdc.ComparisonRow{}
+	for col, f := range foods {
+		values := fdc.NewNutrientValues(f.Nutrients)
+		cf := fdc.ComparisonFood{FdcID: f.Food.FdcID, Description: f.Food.Description, Manufacturer: f.Food.Manufacturer}
+		switch basis {
+		case PER100G:
+			cf.Weight = 100
+		case SERVING:
+			cf.Portion, cf.Weight = f.Portion, f.ServingWeight
+		case PER100KCAL:
+			if kcal := values[fdc.ENERGY]; kcal > 0 {
+				cf.Weight = math.Round(100*100/kcal*10) / 10
+			}
+		}
+		cmp.Foods = append(cmp.Foods, cf)
+		for _, nd := range f.Nutrients {
+			if len(want) > 0 && !want[nd.Nutrientno] {
+				continue
+			}
+			r, ok := rows[nd.Nutrientno]
+			if !ok {
+				r = &fdc.ComparisonRow{Nutrientno: nd.Nutrientno, Nutrient: nd.Nutrient, Unit: nd.Unit, Values: make([]*float64, len(foods))}
+				rows[nd.Nutrientno] = r
+			}
+			// foods without energy have no values per 100 kcal
+			if cf.Weight > 0 {
+				v := math.Round(nd.Value*cf.Weight/100*1000) / 1000
+				r.Values[col] = &v
+			}
+		}
+	}
+	for _, r := range rows {
+		highlight(r, cmp.Foods)
+		cmp.Nutrients = append(cmp.Nutrients, *r)
+	}
+	sort.Slice(cmp.Nutrients, func(i, j int) bool { return cmp.Nutrients[i].Nutrientno < cmp.Nutrients[j].Nutrientno })
+	return cmp, nil
+}
+
+// highlight flags the foods with the highest and lowest values of a row and the difference
+// between them
+func highlight(r *fdc.ComparisonRow, foods []fdc.ComparisonFood) {
+	hi, lo, count := math.Inf(-1), math.Inf(1), 0
+	for _, v := range r.Values {
+		if v != nil {
+			hi, lo = math.Max(hi, *v), math.Min(lo, *v)
+			count++
+		}
+	}
+	if count < 2 || hi == lo {
+		return
+	}
+	r.Difference = math.Round((hi-lo)*1000) / 1000
+	for i, v := range r.Values {
+		if v == nil {
+			continue
+		}
+		if *v == hi {
+			r.Highest = append(r.Highest, foods[i].FdcID)
+		}
+		if *v == lo {
+			r.Lowest = append(r.Lowest, foods[i].FdcID)
+		}
+	}
+}
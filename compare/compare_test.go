@@ -0,0 +1,62 @@
+package compare
+
+import (
+	"testing"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+var foods = []Food{
+	{
+		Food: fdc.Food{FdcID: "1", Description: "Cookies"},
+		Nutrients: []fdc.NutrientData{{Nutrientno: fdc.PROTEIN, Nutrient: "Protein", Unit: "g", Value: 5},
+			{Nutrientno: fdc.ENERGY, Nutrient: "Energy", Unit: "kcal", Value: 500}},
+		Portion: "2 cookies (30g)", ServingWeight: 30,
+	},
+	{
+		Food: fdc.Food{FdcID: "2", Description: "Broccoli"},
+		Nutrients: []fdc.NutrientData{{Nutrientno: fdc.ENERGY, Nutrient: "Energy", Unit: "kcal", Value: 25},
+			{Nutrientno: fdc.PROTEIN, Nutrient: "Protein", Unit: "g", Value: 2.5}, {Nutrientno: fdc.FIBER, Nutrient: "Fiber", Unit: "g", Value: 2.6}},
+		Portion: "1 cup (90g)", ServingWeight: 90,
+	},
+}
+
+func TestCompare(t *testing.T) {
+	cmp, err := Compare(foods, PER100KCAL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cmp.Nutrients) != 3 || cmp.Nutrients[0].Nutrientno != fdc.PROTEIN || cmp.Foods[1].Weight != 400 {
+		t.Fatalf("comparison is %+v", cmp)
+	}
+	protein := cmp.Nutrients[0]
+	if *protein.Values[0] != 1 || *protein.Values[1] != 10 || protein.Highest[0] != "2" || protein.Lowest[0] != "1" || protein.Difference != 9 {
+		t.Errorf("protein per 100 kcal is %+v", protein)
+	}
+	// fiber is only reported for broccoli and energy is the same per 100 kcal
+	for _, r := range cmp.Nutrients[1:] {
+		if len(r.Highest) > 0 || len(r.Lowest) > 0 {
+			t.Errorf("Expecting no highest or lowest for %d", r.Nutrientno)
+		}
+	}
+	if cmp.Nutrients[2].Values[0] != nil {
+		t.Errorf("Expecting a null fiber value for cookies")
+	}
+	if cmp, _ = Compare(foods, SERVING, []int{fdc.PROTEIN}); len(cmp.Nutrients) != 1 || *cmp.Nutrients[0].Values[0] != 1.5 || *cmp.Nutrients[0].Values[1] != 2.25 {
+		t.Errorf("protein per serving is %+v", cmp.Nutrients)
+	}
+	if _, err = Compare(foods, "cup", nil); err == nil {
+		t.Errorf("Expecting an error for an unrecognized basis")
+	}
+}
+
+func TestValidBasis(t *testing.T) {
+	for _, b := range Bases() {
+		if err := ValidBasis(b); err != nil {
+			t.Errorf("%s: %v", b, err)
+		}
+	}
+	if err := ValidBasis("cup"); err == nil {
+		t.Errorf("Expecting an error for an unrecognized basis")
+	}
+}
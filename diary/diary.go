@@ -0,0 +1,163 @@
+// Package diary totals the nutrients of the foods logged in a user's food diary by day and week and
+// follows their trends
+package diary
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/littlebunch/fdc-api/dri"
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+// DAY etc are the periods diary entries are totaled by
+const (
+	DAY  = "day"
+	WEEK = "week"
+)
+
+// BREAKFAST etc are the meals an entry may be logged to
+const (
+	BREAKFAST = "breakfast"
+	LUNCH     = "lunch"
+	DINNER    = "dinner"
+	SNACK     = "snack"
+)
+
+// DateFormat is the layout of diary dates
+const DateFormat = "2006-01-02"
+
+// movingDays is the number of days in a trend's moving average
+const movingDays = 7
+
+// ValidMeal returns an error if a meal isn't empty or one of the diary's meals
+func ValidMeal(meal string) error {
+	switch meal {
+	case "", BREAKFAST, LUNCH, DINNER, SNACK:
+		return nil
+	}
+	return fmt.Errorf("Unrecognized meal %s.  Must be '%s', '%s', '%s' or '%s'", meal, BREAKFAST, LUNCH, DINNER, SNACK)
+}
+
+// Totals sums the nutrients of entries by day or by week.  Weeks start on Monday.  Periods without
+// entries are omitted.
+func Totals(entries []fdc.DiaryEntry, period string) ([]fdc.DiaryPeriod, error) {
+	type total struct {
+		p      fdc.DiaryPeriod
+		days   map[string]bool
+		values fdc.NutrientValues
+	}
+	if period != DAY && period != WEEK {
+		return nil, fmt.Errorf("Unrecognized period %s.  Must be '%s' or '%s'", period, DAY, WEEK)
+	}
+	totals := map[string]*total{}
+	meta := map[int]fdc.RecipeNutrient{}
+	for _, e := range entries {
+		d, err := time.Parse(DateFormat, e.Date)
+		if err != nil {
+			return nil, fmt.Errorf("entry %s has an invalid date %s", e.ID, e.Date)
+		}
+		start, end := d, d
+		if period == WEEK {
+			start = d.AddDate(0, 0, -((int(d.Weekday()) + 6) % 7))
+			end = start.AddDate(0, 0, 6)
+		}
+		key := start.Format(DateFormat)
+		t, ok := totals[key]
+		if !ok {
+			t = &total{p: fdc.DiaryPeriod{Start: key, End: end.Format(DateFormat)}, days: map[string]bool{}, values: fdc.NutrientValues{}}
+			totals[key] = t
+		}
+		t.p.Entries++
+		t.days[e.Date] = true
+		for _, n := range e.Nutrients {
+			t.values[n.Nutrientno] += n.Value
+			meta[n.Nutrientno] = n
+		}
+	}
+	var periods []fdc.DiaryPeriod
+	for _, t := range totals {
+		t.p.Days = len(t.days)
+		for n, v := range t.values {
+			dn := fdc.DiaryNutrient{Nutrientno: n, Nutrient: meta[n].Nutrient, Unit: meta[n].Unit, Value: round(v), Average: round(v / float64(t.p.Days))}
+			if dv, ok := dri.DailyValues[n]; ok && dv.Value > 0 {
+				pct := math.Round(dn.Average/dv.Value*1000) / 10
+				dn.PercentDV = &pct
+			}
+			t.p.Nutrients = append(t.p.Nutrients, dn)
+		}
+		sort.Slice(t.p.Nutrients, func(i, j int) bool { return t.p.Nutrients[i].Nutrientno < t.p.Nutrients[j].Nutrientno })
+		periods = append(periods, t.p)
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].Start < periods[j].Start })
+	return periods, nil
+}
+
+// Trends returns the daily totals of nutrients over the days with entries, each day's average over
+// the 7 days ending with it, and the least squares slope of the totals per day
+func Trends(entries []fdc.DiaryEntry, nutrients []int) ([]fdc.DiaryTrend, error) {
+	days, err := Totals(entries, DAY)
+	if err != nil {
+		return nil, err
+	}
+	var trends []fdc.DiaryTrend
+	for _, n := range nutrients {
+		tr := fdc.DiaryTrend{Nutrientno: n, Days: []fdc.DiaryPoint{}}
+		var xs, ys []float64
+		for _, d := range days {
+			v := 0.0
+			for _, dn := range d.Nutrients {
+				if dn.Nutrientno == n {
+					v, tr.Nutrient, tr.Unit = dn.Value, dn.Nutrient, dn.Unit
+				}
+			}
+			date, _ := time.Parse(DateFormat, d.Start)
+			xs = append(xs, date.Sub(entryEpoch(days)).Hours()/24)
+			ys = append(ys, v)
+			tr.Days = append(tr.Days, fdc.DiaryPoint{Date: d.Start, Value: v})
+		}
+		for i := range tr.Days {
+			sum, count := 0.0, 0
+			for j := i; j >= 0 && xs[i]-xs[j] < movingDays; j-- {
+				sum += ys[j]
+				count++
+			}
+			tr.Days[i].MovingAverage = round(sum / float64(count))
+		}
+		tr.Slope = slope(xs, ys)
+		trends = append(trends, tr)
+	}
+	return trends, nil
+}
+
+// entryEpoch returns the first day totaled
+func entryEpoch(days []fdc.DiaryPeriod) time.Time {
+	t, _ := time.Parse(DateFormat, days[0].Start)
+	return t
+}
+
+// slope returns the least squares slope of ys over xs
+func slope(xs []float64, ys []float64) float64 {
+	n := float64(len(xs))
+	if n < 2 {
+		return 0
+	}
+	var sx, sy, sxy, sxx float64
+	for i := range xs {
+		sx += xs[i]
+		sy += ys[i]
+		sxy += xs[i] * ys[i]
+		sxx += xs[i] * xs[i]
+	}
+	d := n*sxx - sx*sx
+	if d == 0 {
+		return 0
+	}
+	return round((n*sxy - sx*sy) / d)
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,49 @@
+package diary
+
+import (
+	"testing"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+func entry(date string, kcal float64, sodium float64) fdc.DiaryEntry {
+	return fdc.DiaryEntry{ID: date, Date: date, Nutrients: []fdc.RecipeNutrient{
+		{Nutrientno: fdc.ENERGY, Nutrient: "Energy", Unit: "kcal", Value: kcal},
+		{Nutrientno: fdc.SODIUM, Nutrient: "Sodium, Na", Unit: "mg", Value: sodium}}}
+}
+
+var entries = []fdc.DiaryEntry{
+	entry("2026-10-12", 600, 800), entry("2026-10-12", 1200, 1500), entry("2026-10-14", 2000, 2000),
+	entry("2026-10-18", 2200, 2530), entry("2026-10-19", 1800, 1000),
+}
+
+func TestTotals(t *testing.T) {
+	days, err := Totals(entries, DAY)
+	if err != nil || len(days) != 4 || days[0].Entries != 2 || days[0].Nutrients[0].Value != 1800 {
+		t.Fatalf("daily totals are %+v %v", days, err)
+	}
+	// weeks start Monday October 12 and 19
+	weeks, _ := Totals(entries, WEEK)
+	if len(weeks) != 2 || weeks[0].Start != "2026-10-12" || weeks[0].End != "2026-10-18" || weeks[0].Days != 3 {
+		t.Fatalf("weekly totals are %+v", weeks)
+	}
+	sodium := weeks[0].Nutrients[1]
+	if sodium.Value != 6830 || sodium.Average != 2276.67 || *sodium.PercentDV != 99 {
+		t.Errorf("weekly sodium is %+v %.1f", sodium, *sodium.PercentDV)
+	}
+	if _, err = Totals(entries, "month"); err == nil {
+		t.Errorf("Expecting an error for an unrecognized period")
+	}
+}
+
+func TestTrends(t *testing.T) {
+	trends, err := Trends(entries, []int{fdc.ENERGY})
+	if err != nil || len(trends) != 1 || len(trends[0].Days) != 4 {
+		t.Fatalf("trends are %+v %v", trends, err)
+	}
+	// the moving average on the 19th covers the 14th, 18th and 19th
+	energy := trends[0]
+	if energy.Days[3].MovingAverage != 2000 || energy.Slope <= 0 {
+		t.Errorf("energy trend is %+v", energy)
+	}
+}
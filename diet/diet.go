@@ -0,0 +1,313 @@
+// Package diet classifies foods' suitability for diets and FDA nutrient content claims from
+// their ingredients, food group and nutrient values
+package diet
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/littlebunch/fdc-api/ingredient"
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+// VEGAN etc are the diets and nutrient content claims foods are classified for
+const (
+	VEGAN           = "vegan"
+	VEGETARIAN      = "vegetarian"
+	GLUTENFREE      = "glutenFree"
+	KETO            = "keto"
+	LOWSODIUM       = "lowSodium"
+	LOWFAT          = "lowFat"
+	LOWSATURATEDFAT = "lowSaturatedFat"
+	LOWCALORIE      = "lowCalorie"
+	LOWCHOLESTEROL  = "lowCholesterol"
+	SUGARFREE       = "sugarFree"
+	HIGHFIBER       = "highFiber"
+	HIGHPROTEIN     = "highProtein"
+)
+
+// SUITABLE etc are the statuses of a classification
+const (
+	SUITABLE   = "suitable"
+	UNSUITABLE = "unsuitable"
+	UNKNOWN    = "unknown"
+)
+
+var (
+	meat   = regexp.MustCompile(`(^|[^a-z])(beef|pork|chicken|turkey|lamb|veal|mutton|venison|bison|duck|goose|bacon|ham|sausages?|pepperoni|salami|prosciutto|meats?|poultry|gelatine?|lard|tallow|suet|collagen|bone|rennet|carmine|cochineal|isinglass|animal fat)([^a-z]|$)`)
+	animal = regexp.MustCompile(`(^|[^a-z])(honey|beeswax|lanolin|shellac)([^a-z]|$)`)
+	gluten = regexp.MustCompile(`(^|[^a-z])(rye|barley|malt|malted|triticale|brewer's yeast)([^a-z]|$)`)
+	// food group descriptions of SR and FNDDS groups made up of meat, fish or animal products
+	meatGroup   = regexp.MustCompile(`beef|pork|poultry|lamb|veal|game|sausage|luncheon|meat|fish|shellfish`)
+	animalGroup = regexp.MustCompile(`dairy|milk|egg`)
+	grainGroup  = regexp.MustCompile(`baked|cereal|grain|pasta`)
+)
+
+// Diets returns the names of the diets and claims foods are classified for in sorted order
+func Diets() []string {
+	d := []string{VEGAN, VEGETARIAN, GLUTENFREE, KETO, LOWSODIUM, LOWFAT, LOWSATURATEDFAT, LOWCALORIE,
+		LOWCHOLESTEROL, SUGARFREE, HIGHFIBER, HIGHPROTEIN}
+	sort.Strings(d)
+	return d
+}
+
+// Valid returns an error for any name which isn't a diet
+func Valid(names []string) error {
+	for _, n := range names {
+		found := false
+		for _, d := range Diets() {
+			if n == d {
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("Unrecognized diet %s.  Must be one of %s", n, strings.Join(Diets(), ", "))
+		}
+	}
+	return nil
+}
+
+// Classify returns a food's suitability for each diet.  Diets are decided from the food's
+// ingredients, or it's description when it has none, and it's food group.  Nutrient content claims
+// use the definitions in 21 CFR 101.54-101.62 applied to the servingWeight in grams, or also to
+// 50 grams for servings of 30 grams or less, in place of the reference amount customarily consumed.
+func Classify(f fdc.Food, values fdc.NutrientValues, servingWeight float64) []fdc.DietClass {
+	text := strings.ToLower(f.Ingredients)
+	source := "ingredients"
+	if text == "" {
+		text = strings.ToLower(f.Description)
+		source = "description"
+	}
+	group := ""
+	if f.Group != nil {
+		group = strings.ToLower(f.Group.Description)
+	}
+	contains, _ := ingredient.DetectAllergens(text)
+	found := map[string][]string{}
+	for _, m := range contains {
+		found[m.Allergen] = m.Terms
+	}
+	c := classifier{values: values, weight: servingWeight}
+	return []fdc.DietClass{
+		vegan(text, source, group, found),
+		vegetarian(text, source, group, found),
+		glutenFree(text, source, group, found),
+		c.keto(),
+		c.claim(LOWSODIUM, "101.61", limit{fdc.SODIUM, "sodium", "mg", 140}),
+		c.claim(LOWFAT, "101.62", limit{fdc.TOTALFAT, "fat", "g", 3}),
+		c.lowSaturatedFat(),
+		c.claim(LOWCALORIE, "101.60", limit{fdc.ENERGY, "energy", "kcal", 40}),
+		c.claim(LOWCHOLESTEROL, "101.62", limit{fdc.CHOLESTEROL, "cholesterol", "mg", 20}, limit{fdc.SATURATEDFAT, "saturated fat", "g", 2}),
+		c.sugarFree(),
+		c.minimum(HIGHFIBER, limit{fdc.FIBER, "fiber", "g", 5.6}),
+		c.minimum(HIGHPROTEIN, limit{fdc.PROTEIN, "protein", "g", 10}),
+	}
+}
+
+func vegan(text string, source string, group string, found map[string][]string) fdc.DietClass {
+	d := fdc.DietClass{Diet: VEGAN, Status: SUITABLE}
+	if meatGroup.MatchString(group) || animalGroup.MatchString(group) {
+		d.Reasons = append(d.Reasons, fmt.Sprintf("food group %s is an animal product", group))
+	}
+	for _, a := range []string{ingredient.MILK, ingredient.EGG, ingredient.FISH, ingredient.SHELLFISH} {
+		if t, ok := found[a]; ok {
+			d.Reasons = append(d.Reasons, fmt.Sprintf("%s found in %s: %s", a, source, strings.Join(t, ", ")))
+		}
+	}
+	for _, r := range []*regexp.Regexp{meat, animal} {
+		if t := terms(r, text); len(t) > 0 {
+			d.Reasons = append(d.Reasons, fmt.Sprintf("animal products found in %s: %s", source, strings.Join(t, ", ")))
+		}
+	}
+	if len(d.Reasons) > 0 {
+		d.Status = UNSUITABLE
+	} else {
+		d.Reasons = []string{fmt.Sprintf("no animal products found in %s or food group", source)}
+	}
+	return d
+}
+
+func vegetarian(text string, source string, group string, found map[string][]string) fdc.DietClass {
+	d := fdc.DietClass{Diet: VEGETARIAN, Status: SUITABLE}
+	if meatGroup.MatchString(group) {
+		d.Reasons = append(d.Reasons, fmt.Sprintf("food group %s is meat or fish", group))
+	}
+	for _, a := range []string{ingredient.FISH, ingredient.SHELLFISH} {
+		if t, ok := found[a]; ok {
+			d.Reasons = append(d.Reasons, fmt.Sprintf("%s found in %s: %s", a, source, strings.Join(t, ", ")))
+		}
+	}
+	if t := terms(meat, text); len(t) > 0 {
+		d.Reasons = append(d.Reasons, fmt.Sprintf("meat or slaughter products found in %s: %s", source, strings.Join(t, ", ")))
+	}
+	if len(d.Reasons) > 0 {
+		d.Status = UNSUITABLE
+	} else {
+		d.Reasons = []string{fmt.Sprintf("no meat, fish or slaughter products found in %s or food group", source)}
+	}
+	return d
+}
+
+// glutenFree follows 21 CFR 101.91 in excluding foods with wheat, rye, barley or their crossbreeds.
+// The 20 ppm gluten limit can't be checked from the data.
+func glutenFree(text string, source string, group string, found map[string][]string) fdc.DietClass {
+	d := fdc.DietClass{Diet: GLUTENFREE, Status: SUITABLE}
+	if t, ok := found[ingredient.WHEAT]; ok {
+		d.Reasons = append(d.Reasons, fmt.Sprintf("wheat found in %s: %s", source, strings.Join(t, ", ")))
+	}
+	if t := terms(gluten, text); len(t) > 0 {
+		d.Reasons = append(d.Reasons, fmt.Sprintf("gluten grains found in %s: %s", source, strings.Join(t, ", ")))
+	}
+	switch {
+	case len(d.Reasons) > 0:
+		d.Status = UNSUITABLE
+	case source == "description" && grainGroup.MatchString(group):
+		d.Status = UNKNOWN
+		d.Reasons = []string{fmt.Sprintf("food group %s may contain gluten grains and the food has no ingredients", group)}
+	default:
+		d.Reasons = []string{fmt.Sprintf("no gluten grains found in %s", source)}
+	}
+	return d
+}
+
+// limit is a nutrient's maximum or minimum amount for a claim
+type limit struct {
+	nutrientno int
+	name       string
+	unit       string
+	amount     float64
+}
+
+type classifier struct {
+	values fdc.NutrientValues
+	weight float64
+}
+
+// returns the amount of a nutrient in a weight of the food and whether the food has a value for it
+func (c classifier) amount(n int, weight float64) (float64, bool) {
+	v, ok := c.values[n]
+	return v * weight / 100, ok
+}
+
+// returns the weights a claim is checked against: the serving and also 50 grams for small servings
+func (c classifier) weights() []float64 {
+	if c.weight <= 30 {
+		return []float64{c.weight, 50}
+	}
+	return []float64{c.weight}
+}
+
+// claim checks that each limit is not exceeded in the serving
+func (c classifier) claim(diet string, cfr string, limits ...limit) fdc.DietClass {
+	d := fdc.DietClass{Diet: diet, Status: SUITABLE}
+	for _, l := range limits {
+		for _, w := range c.weights() {
+			v, ok := c.amount(l.nutrientno, w)
+			switch {
+			case !ok:
+				return unknown(diet, l.name)
+			case v > l.amount:
+				d.Status = UNSUITABLE
+				d.Reasons = append(d.Reasons, fmt.Sprintf("%s %.1f %s per %.0f g is more than %g %s (21 CFR %s)", l.name, v, l.unit, w, l.amount, l.unit, cfr))
+			default:
+				d.Reasons = append(d.Reasons, fmt.Sprintf("%s %.1f %s per %.0f g is at most %g %s (21 CFR %s)", l.name, v, l.unit, w, l.amount, l.unit, cfr))
+			}
+		}
+	}
+	return d
+}
+
+// minimum checks for a "high" claim of at least 20% of the Daily Value per serving (21 CFR 101.54)
+func (c classifier) minimum(diet string, l limit) fdc.DietClass {
+	v, ok := c.amount(l.nutrientno, c.weight)
+	if !ok {
+		return unknown(diet, l.name)
+	}
+	d := fdc.DietClass{Diet: diet, Status: SUITABLE}
+	if v < l.amount {
+		d.Status = UNSUITABLE
+		d.Reasons = []string{fmt.Sprintf("%s %.1f %s per %.0f g is less than 20%% of the Daily Value, %g %s (21 CFR 101.54)", l.name, v, l.unit, c.weight, l.amount, l.unit)}
+	} else {
+		d.Reasons = []string{fmt.Sprintf("%s %.1f %s per %.0f g is at least 20%% of the Daily Value, %g %s (21 CFR 101.54)", l.name, v, l.unit, c.weight, l.amount, l.unit)}
+	}
+	return d
+}
+
+// lowSaturatedFat requires at most 1 g of saturated fat with no more than 15% of calories from it
+func (c classifier) lowSaturatedFat() fdc.DietClass {
+	d := c.claim(LOWSATURATEDFAT, "101.62", limit{fdc.SATURATEDFAT, "saturated fat", "g", 1})
+	if d.Status != SUITABLE {
+		return d
+	}
+	sf, _ := c.amount(fdc.SATURATEDFAT, 100)
+	e, ok := c.amount(fdc.ENERGY, 100)
+	if !ok {
+		return unknown(LOWSATURATEDFAT, "energy")
+	}
+	if e > 0 && sf*9/e > 0.15 {
+		d.Status = UNSUITABLE
+		d.Reasons = append(d.Reasons, fmt.Sprintf("%.0f%% of calories are from saturated fat, more than 15%% (21 CFR 101.62)", sf*9/e*100))
+	}
+	return d
+}
+
+// sugarFree requires less than 0.5 g of sugars per serving (21 CFR 101.60)
+func (c classifier) sugarFree() fdc.DietClass {
+	v, ok := c.amount(fdc.SUGARS, c.weight)
+	if !ok {
+		return unknown(SUGARFREE, "sugars")
+	}
+	if v >= 0.5 {
+		return fdc.DietClass{Diet: SUGARFREE, Status: UNSUITABLE, Reasons: []string{fmt.Sprintf("sugars %.1f g per %.0f g is 0.5 g or more (21 CFR 101.60)", v, c.weight)}}
+	}
+	return fdc.DietClass{Diet: SUGARFREE, Status: SUITABLE, Reasons: []string{fmt.Sprintf("sugars %.1f g per %.0f g is less than 0.5 g (21 CFR 101.60)", v, c.weight)}}
+}
+
+// keto has no FDA definition.  Foods with no more than 10% of their calories from net carbohydrate,
+// carbohydrate less fiber, are classified as keto friendly.
+func (c classifier) keto() fdc.DietClass {
+	carbs, ok := c.amount(fdc.CARBOHYDRATE, 100)
+	if !ok {
+		return unknown(KETO, "carbohydrate")
+	}
+	e, ok := c.amount(fdc.ENERGY, 100)
+	if !ok {
+		return unknown(KETO, "energy")
+	}
+	fiber, _ := c.amount(fdc.FIBER, 100)
+	net := carbs - fiber
+	if net < 0 {
+		net = 0
+	}
+	pct := 0.0
+	if e > 0 {
+		pct = net * 4 / e * 100
+	}
+	d := fdc.DietClass{Diet: KETO, Status: SUITABLE}
+	if pct > 10 {
+		d.Status = UNSUITABLE
+		d.Reasons = []string{fmt.Sprintf("%.0f%% of calories are from net carbohydrate, more than 10%%", pct)}
+	} else {
+		d.Reasons = []string{fmt.Sprintf("%.0f%% of calories are from net carbohydrate, at most 10%%", pct)}
+	}
+	return d
+}
+
+func unknown(diet string, nutrient string) fdc.DietClass {
+	return fdc.DietClass{Diet: diet, Status: UNKNOWN, Reasons: []string{fmt.Sprintf("no value for %s", nutrient)}}
+}
+
+// returns the distinct terms matched by a regular expression in text
+func terms(r *regexp.Regexp, text string) []string {
+	found := map[string]bool{}
+	var t []string
+	for _, m := range r.FindAllStringSubmatch(text, -1) {
+		if !found[m[2]] {
+			found[m[2]] = true
+			t = append(t, m[2])
+		}
+	}
+	return t
+}
@@ -0,0 +1,48 @@
+package diet
+
+import (
+	"testing"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+func status(dc []fdc.DietClass) map[string]string {
+	s := map[string]string{}
+	for _, d := range dc {
+		s[d.Diet] = d.Status
+	}
+	return s
+}
+
+func TestClassify(t *testing.T) {
+	cookie := fdc.Food{Description: "Sandwich cookies", Ingredients: "Enriched flour (wheat flour, niacin), sugar, palm oil, cocoa, whey, salt, soy lecithin."}
+	values := fdc.NutrientValues{fdc.ENERGY: 470, fdc.PROTEIN: 5, fdc.TOTALFAT: 20, fdc.CARBOHYDRATE: 70, fdc.FIBER: 3,
+		fdc.SUGARS: 40, fdc.SODIUM: 350, fdc.SATURATEDFAT: 6, fdc.CHOLESTEROL: 0}
+	want := map[string]string{VEGAN: UNSUITABLE, VEGETARIAN: SUITABLE, GLUTENFREE: UNSUITABLE, KETO: UNSUITABLE,
+		LOWSODIUM: SUITABLE, LOWFAT: UNSUITABLE, LOWSATURATEDFAT: UNSUITABLE, LOWCALORIE: UNSUITABLE,
+		LOWCHOLESTEROL: UNSUITABLE, SUGARFREE: UNSUITABLE, HIGHFIBER: UNSUITABLE, HIGHPROTEIN: UNSUITABLE}
+	// low cholesterol fails on 2.04 g of saturated fat.  The 140 mg sodium limit holds for a 34 g
+	// serving, 119 mg, but not also for 50 g of a 30 g serving, 175 mg
+	got := status(Classify(cookie, values, 34))
+	for d, s := range want {
+		if got[d] != s {
+			t.Errorf("%s is %s SB %s", d, got[d], s)
+		}
+	}
+	if got := status(Classify(cookie, values, 30)); got[LOWSODIUM] != UNSUITABLE {
+		t.Errorf("%s is %s SB %s for a 30 g serving", LOWSODIUM, got[LOWSODIUM], UNSUITABLE)
+	}
+	broccoli := fdc.Food{Description: "Broccoli, raw", Group: &fdc.FoodGroup{Description: "Vegetables and Vegetable Products"}}
+	got = status(Classify(broccoli, fdc.NutrientValues{fdc.ENERGY: 34, fdc.CARBOHYDRATE: 6.6, fdc.FIBER: 2.6}, 91))
+	if got[VEGAN] != SUITABLE || got[GLUTENFREE] != SUITABLE || got[LOWSODIUM] != UNKNOWN {
+		t.Errorf("broccoli is %v", got)
+	}
+	bread := fdc.Food{Description: "Bread, white", Group: &fdc.FoodGroup{Description: "Baked Products"}}
+	if got := status(Classify(bread, fdc.NutrientValues{}, 25)); got[GLUTENFREE] != UNKNOWN {
+		t.Errorf("%s is %s SB %s for bread without ingredients", GLUTENFREE, got[GLUTENFREE], UNKNOWN)
+	}
+	beef := fdc.Food{Description: "Beef, ground, 80% lean", Group: &fdc.FoodGroup{Description: "Beef Products"}}
+	if got := status(Classify(beef, fdc.NutrientValues{}, 100)); got[VEGETARIAN] != UNSUITABLE {
+		t.Errorf("%s is %s SB %s for beef", VEGETARIAN, got[VEGETARIAN], UNSUITABLE)
+	}
+}
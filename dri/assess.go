@@ -0,0 +1,63 @@
+package dri
+
+import (
+	"sort"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+// Assess compares amounts of nutrients to their reference intakes for a life stage.  Without
+// a life stage only Daily Values are compared.  Nutrients without a reference intake are skipped.
+func Assess(values fdc.NutrientValues, ls *LifeStage) ([]fdc.NutrientIntake, error) {
+	g, dv := -1, true
+	if ls != nil {
+		var err error
+		if g, err = ls.group(); err != nil {
+			return nil, err
+		}
+		dv = ls.Age >= 4
+	}
+	var ni []fdc.NutrientIntake
+	for _, n := range nutrientNumbers() {
+		v, ok := values[n]
+		if !ok {
+			continue
+		}
+		r := reference(n, g, dv)
+		if r.DV == 0 && r.RDA == 0 && r.AI == 0 && r.UL == 0 && r.CDRR == 0 {
+			continue
+		}
+		i := fdc.NutrientIntake{Nutrientno: n, Name: r.Name, Unit: r.Unit, Value: v}
+		i.PercentDV = percent(v, r.DV)
+		i.PercentRDA = percent(v, r.RDA)
+		i.PercentAI = percent(v, r.AI)
+		i.PercentUL = percent(v, r.UL)
+		i.ULExceeded = r.UL > 0 && v > r.UL
+		i.CDRRExceeded = r.CDRR > 0 && v > r.CDRR
+		ni = append(ni, i)
+	}
+	return ni, nil
+}
+
+func percent(v float64, ref float64) *float64 {
+	if ref <= 0 {
+		return nil
+	}
+	p := v / ref * 100
+	return &p
+}
+
+// nutrientNumbers returns the nutrients with a Daily Value or DRI in ascending order
+func nutrientNumbers() []int {
+	var n []int
+	for k := range DailyValues {
+		n = append(n, k)
+	}
+	for k := range intakes {
+		if _, ok := DailyValues[k]; !ok {
+			n = append(n, k)
+		}
+	}
+	sort.Ints(n)
+	return n
+}
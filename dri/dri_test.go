@@ -0,0 +1,70 @@
+package dri
+
+import (
+	"testing"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+func TestLifeStage(t *testing.T) {
+	tests := []struct {
+		ls    LifeStage
+		group string
+	}{
+		{LifeStage{Age: 0.3}, "Infants 0-6 mo"},
+		{LifeStage{Age: 6}, "Children 4-8 y"},
+		{LifeStage{Sex: MALE, Age: 45}, "Males 31-50 y"},
+		{LifeStage{Sex: FEMALE, Age: 80}, "Females > 70 y"},
+		{LifeStage{Sex: FEMALE, Age: 25, Pregnant: true}, "Pregnancy 19-30 y"},
+	}
+	for _, tt := range tests {
+		if g := tt.ls.Group(); g != tt.group {
+			t.Errorf("%v group is %s SB %s", tt.ls, g, tt.group)
+		}
+	}
+	for _, ls := range []LifeStage{{Age: 30}, {Sex: MALE, Age: 30, Pregnant: true}, {Sex: FEMALE, Age: 30, Pregnant: true, Lactating: true}, {Age: -1}} {
+		if err := ls.Validate(); err == nil {
+			t.Errorf("Expecting an error validating %v", ls)
+		}
+	}
+}
+
+func TestAssess(t *testing.T) {
+	ls := LifeStage{Sex: FEMALE, Age: 30}
+	intakes, err := Assess(fdc.NutrientValues{303: 9, 401: 2100, 307: 2400, 999: 1}, &ls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(intakes) != 3 {
+		t.Fatalf("intakes are %v", intakes)
+	}
+	for _, i := range intakes {
+		switch i.Nutrientno {
+		case 303:
+			if *i.PercentRDA != 50 || *i.PercentDV != 50 || i.ULExceeded {
+				t.Errorf("iron is %v", i)
+			}
+		case 401:
+			if !i.ULExceeded || i.PercentRDA == nil {
+				t.Errorf("vitamin C is %v", i)
+			}
+		case 307:
+			if !i.CDRRExceeded || i.PercentAI == nil || i.PercentRDA != nil {
+				t.Errorf("sodium is %v", i)
+			}
+		}
+	}
+	if intakes, _ = Assess(fdc.NutrientValues{303: 9}, nil); intakes[0].PercentRDA != nil || *intakes[0].PercentDV != 50 {
+		t.Errorf("iron without a life stage is %v", intakes[0])
+	}
+}
+
+func TestTables(t *testing.T) {
+	for n, i := range intakes {
+		for _, l := range [][]float64{i.amount, i.ul, i.cdrr} {
+			if len(l) != 0 && len(l) != len(groups) {
+				t.Errorf("nutrient %d has %d values SB %d", n, len(l), len(groups))
+			}
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package dri
+
+// Reference is the reference intakes of a nutrient.  Values of 0 mean none has been established.
+type Reference struct {
+	Nutrientno int     `json:"nutrientNumber"`
+	Name       string  `json:"nutrientName"`
+	Unit       string  `json:"unit"`
+	DV         float64 `json:"dailyValue,omitempty"`
+	RDA        float64 `json:"rda,omitempty"`
+	AI         float64 `json:"ai,omitempty"`
+	UL         float64 `json:"ul,omitempty"`
+	CDRR       float64 `json:"cdrr,omitempty"`
+}
+
+// DailyValue is an FDA Daily Value for adults and children 4 years and older
+type DailyValue struct {
+	Name  string
+	Unit  string
+	Value float64
+}
+
+// DailyValues are the 2016 FDA Daily Values for adults and children 4 years and older
+// (21 CFR 101.9(c)) keyed by nutrient number
+var DailyValues = map[int]DailyValue{
+	203: {"Protein", "g", 50},
+	204: {"Total lipid (fat)", "g", 78},
+	205: {"Carbohydrate, by difference", "g", 275},
+	291: {"Fiber, total dietary", "g", 28},
+	301: {"Calcium, Ca", "mg", 1300},
+	303: {"Iron, Fe", "mg", 18},
+	304: {"Magnesium, Mg", "mg", 420},
+	305: {"Phosphorus, P", "mg", 1250},
+	306: {"Potassium, K", "mg", 4700},
+	307: {"Sodium, Na", "mg", 2300},
+	309: {"Zinc, Zn", "mg", 11},
+	312: {"Copper, Cu", "mg", 0.9},
+	314: {"Iodine, I", "mcg", 150},
+	315: {"Manganese, Mn", "mg", 2.3},
+	317: {"Selenium, Se", "mcg", 55},
+	320: {"Vitamin A, RAE", "mcg", 900},
+	323: {"Vitamin E (alpha-tocopherol)", "mg", 15},
+	328: {"Vitamin D (D2 + D3)", "mcg", 20},
+	401: {"Vitamin C, total ascorbic acid", "mg", 90},
+	404: {"Thiamin", "mg", 1.2},
+	405: {"Riboflavin", "mg", 1.3},
+	406: {"Niacin", "mg", 16},
+	410: {"Pantothenic acid", "mg", 5},
+	415: {"Vitamin B-6", "mg", 1.7},
+	416: {"Biotin", "mcg", 30},
+	418: {"Vitamin B-12", "mcg", 2.4},
+	421: {"Choline, total", "mg", 550},
+	430: {"Vitamin K (phylloquinone)", "mcg", 120},
+	435: {"Folate, DFE", "mcg", 400},
+	539: {"Sugars, added", "g", 50},
+	601: {"Cholesterol", "mg", 300},
+	606: {"Fatty acids, total saturated", "g", 20},
+}
+
+// intake is the DRI table for a nutrient.  Amounts are RDA's unless aiOnly is set and are
+// listed in the order of the life stage groups as are the ULs and CDRRs.  Infant amounts are
+// always AI's.
+type intake struct {
+	name   string
+	unit   string
+	aiOnly bool
+	amount []float64
+	ul     []float64
+	cdrr   []float64
+}
+
+// intakes are the Dietary Reference Intakes from the National Academies DRI tables keyed by nutrient
+// number.  ULs for vitamin A and folate apply only to preformed vitamin A (retinol) and folic acid so
+// are listed on those nutrients.  ULs for vitamin E, niacin and magnesium apply only to supplements
+// and fortificants and are not included.
+var intakes = map[int]intake{
+	203: {name: "Protein", unit: "g",
+		amount: []float64{9.1, 11, 13, 19, 34, 52, 56, 56, 56, 56, 34, 46, 46, 46, 46, 46, 71, 71, 71, 71, 71, 71}},
+	205: {name: "Carbohydrate, by difference", unit: "g",
+		amount: []float64{60, 95, 130, 130, 130, 130, 130, 130, 130, 130, 130, 130, 130, 130, 130, 130, 175, 175, 175, 210, 210, 210}},
+	291: {name: "Fiber, total dietary", unit: "g", aiOnly: true,
+		amount: []float64{0, 0, 19, 25, 31, 38, 38, 38, 30, 30, 26, 26, 25, 25, 21, 21, 28, 28, 28, 29, 29, 29}},
+	301: {name: "Calcium, Ca", unit: "mg",
+		amount: []float64{200, 260, 700, 1000, 1300, 1300, 1000, 1000, 1000, 1200, 1300, 1300, 1000, 1000, 1200, 1200, 1300, 1000, 1000, 1300, 1000, 1000},
+		ul:     []float64{1000, 1500, 2500, 2500, 3000, 3000, 2500, 2500, 2000, 2000, 3000, 3000, 2500, 2500, 2000, 2000, 3000, 2500, 2500, 3000, 2500, 2500}},
+	303: {name: "Iron, Fe", unit: "mg",
+		amount: []float64{0.27, 11, 7, 10, 8, 11, 8, 8, 8, 8, 8, 15, 18, 18, 8, 8, 27, 27, 27, 10, 9, 9},
+		ul:     []float64{40, 40, 40, 40, 40, 45, 45, 45, 45, 45, 40, 45, 45, 45, 45, 45, 45, 45, 45, 45, 45, 45}},
+	304: {name: "Magnesium, Mg", unit: "mg",
+		amount: []float64{30, 75, 80, 130, 240, 410, 400, 420, 420, 420, 240, 360, 310, 320, 320, 320, 400, 350, 360, 360, 310, 320}},
+	305: {name: "Phosphorus, P", unit: "mg",
+		amount: []float64{100, 275, 460, 500, 1250, 1250, 700, 700, 700, 700, 1250, 1250, 700, 700, 700, 700, 1250, 700, 700, 1250, 700, 700},
+		ul:     []float64{0, 0, 3000, 3000, 4000, 4000, 4000, 4000, 4000, 3000, 4000, 4000, 4000, 4000, 4000, 3000, 3500, 3500, 3500, 4000, 4000, 4000}},
+	306: {name: "Potassium, K", unit: "mg", aiOnly: true,
+		amount: []float64{400, 860, 2000, 2300, 2500, 3000, 3400, 3400, 3400, 3400, 2300, 2300, 2600, 2600, 2600, 2600, 2600, 2900, 2900, 2500, 2800, 2800}},
+	307: {name: "Sodium, Na", unit: "mg", aiOnly: true,
+		amount: []float64{110, 370, 800, 1000, 1200, 1500, 1500, 1500, 1500, 1500, 1200, 1500, 1500, 1500, 1500, 1500, 1500, 1500, 1500, 1500, 1500, 1500},
+		cdrr:   []float64{0, 0, 1200, 1500, 1800, 2300, 2300, 2300, 2300, 2300, 1800, 2300, 2300, 2300, 2300, 2300, 2300, 2300, 2300, 2300, 2300, 2300}},
+	309: {name: "Zinc, Zn", unit: "mg",
+		amount: []float64{2, 3, 3, 5, 8, 11, 11, 11, 11, 11, 8, 9, 8, 8, 8, 8, 12, 11, 11, 13, 12, 12},
+		ul:     []float64{4, 5, 7, 12, 23, 34, 40, 40, 40, 40, 23, 34, 40, 40, 40, 40, 34, 40, 40, 34, 40, 40}},
+	312: {name: "Copper, Cu", unit: "mg",
+		amount: []float64{0.2, 0.22, 0.34, 0.44, 0.7, 0.89, 0.9, 0.9, 0.9, 0.9, 0.7, 0.89, 0.9, 0.9, 0.9, 0.9, 1, 1, 1, 1.3, 1.3, 1.3},
+		ul:     []float64{0, 0, 1, 3, 5, 8, 10, 10, 10, 10, 5, 8, 10, 10, 10, 10, 8, 10, 10, 8, 10, 10}},
+	314: {name: "Iodine, I", unit: "mcg",
+		amount: []float64{110, 130, 90, 90, 120, 150, 150, 150, 150, 150, 120, 150, 150, 150, 150, 150, 220, 220, 220, 290, 290, 290},
+		ul:     []float64{0, 0, 200, 300, 600, 900, 1100, 1100, 1100, 1100, 600, 900, 1100, 1100, 1100, 1100, 900, 1100, 1100, 900, 1100, 1100}},
+	315: {name: "Manganese, Mn", unit: "mg", aiOnly: true,
+		amount: []float64{0.003, 0.6, 1.2, 1.5, 1.9, 2.2, 2.3, 2.3, 2.3, 2.3, 1.6, 1.6, 1.8, 1.8, 1.8, 1.8, 2, 2, 2, 2.6, 2.6, 2.6},
+		ul:     []float64{0, 0, 2, 3, 6, 9, 11, 11, 11, 11, 6, 9, 11, 11, 11, 11, 9, 11, 11, 9, 11, 11}},
+	317: {name: "Selenium, Se", unit: "mcg",
+		amount: []float64{15, 20, 20, 30, 40, 55, 55, 55, 55, 55, 40, 55, 55, 55, 55, 55, 60, 60, 60, 70, 70, 70},
+		ul:     []float64{45, 60, 90, 150, 280, 400, 400, 400, 400, 400, 280, 400, 400, 400, 400, 400, 400, 400, 400, 400, 400, 400}},
+	319: {name: "Retinol", unit: "mcg",
+		ul: []float64{600, 600, 600, 900, 1700, 2800, 3000, 3000, 3000, 3000, 1700, 2800, 3000, 3000, 3000, 3000, 2800, 3000, 3000, 2800, 3000, 3000}},
+	320: {name: "Vitamin A, RAE", unit: "mcg",
+		amount: []float64{400, 500, 300, 400, 600, 900, 900, 900, 900, 900, 600, 700, 700, 700, 700, 700, 750, 770, 770, 1200, 1300, 1300}},
+	323: {name: "Vitamin E (alpha-tocopherol)", unit: "mg",
+		amount: []float64{4, 5, 6, 7, 11, 15, 15, 15, 15, 15, 11, 15, 15, 15, 15, 15, 15, 15, 15, 19, 19, 19}},
+	328: {name: "Vitamin D (D2 + D3)", unit: "mcg",
+		amount: []float64{10, 10, 15, 15, 15, 15, 15, 15, 15, 20, 15, 15, 15, 15, 15, 20, 15, 15, 15, 15, 15, 15},
+		ul:     []float64{25, 38, 63, 75, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100}},
+	401: {name: "Vitamin C, total ascorbic acid", unit: "mg",
+		amount: []float64{40, 50, 15, 25, 45, 75, 90, 90, 90, 90, 45, 65, 75, 75, 75, 75, 80, 85, 85, 115, 120, 120},
+		ul:     []float64{0, 0, 400, 650, 1200, 1800, 2000, 2000, 2000, 2000, 1200, 1800, 2000, 2000, 2000, 2000, 1800, 2000, 2000, 1800, 2000, 2000}},
+	404: {name: "Thiamin", unit: "mg",
+		amount: []float64{0.2, 0.3, 0.5, 0.6, 0.9, 1.2, 1.2, 1.2, 1.2, 1.2, 0.9, 1, 1.1, 1.1, 1.1, 1.1, 1.4, 1.4, 1.4, 1.4, 1.4, 1.4}},
+	405: {name: "Riboflavin", unit: "mg",
+		amount: []float64{0.3, 0.4, 0.5, 0.6, 0.9, 1.3, 1.3, 1.3, 1.3, 1.3, 0.9, 1, 1.1, 1.1, 1.1, 1.1, 1.4, 1.4, 1.4, 1.6, 1.6, 1.6}},
+	406: {name: "Niacin", unit: "mg",
+		amount: []float64{2, 4, 6, 8, 12, 16, 16, 16, 16, 16, 12, 14, 14, 14, 14, 14, 18, 18, 18, 17, 17, 17}},
+	415: {name: "Vitamin B-6", unit: "mg",
+		amount: []float64{0.1, 0.3, 0.5, 0.6, 1, 1.3, 1.3, 1.3, 1.7, 1.7, 1, 1.2, 1.3, 1.3, 1.5, 1.5, 1.9, 1.9, 1.9, 2, 2, 2},
+		ul:     []float64{0, 0, 30, 40, 60, 80, 100, 100, 100, 100, 60, 80, 100, 100, 100, 100, 80, 100, 100, 80, 100, 100}},
+	418: {name: "Vitamin B-12", unit: "mcg",
+		amount: []float64{0.4, 0.5, 0.9, 1.2, 1.8, 2.4, 2.4, 2.4, 2.4, 2.4, 1.8, 2.4, 2.4, 2.4, 2.4, 2.4, 2.6, 2.6, 2.6, 2.8, 2.8, 2.8}},
+	421: {name: "Choline, total", unit: "mg", aiOnly: true,
+		amount: []float64{125, 150, 200, 250, 375, 550, 550, 550, 550, 550, 375, 400, 425, 425, 425, 425, 450, 450, 450, 550, 550, 550},
+		ul:     []float64{0, 0, 1000, 1000, 2000, 3000, 3500, 3500, 3500, 3500, 2000, 3000, 3500, 3500, 3500, 3500, 3000, 3500, 3500, 3000, 3500, 3500}},
+	430: {name: "Vitamin K (phylloquinone)", unit: "mcg", aiOnly: true,
+		amount: []float64{2, 2.5, 30, 55, 60, 75, 120, 120, 120, 120, 60, 75, 90, 90, 90, 90, 75, 90, 90, 75, 90, 90}},
+	431: {name: "Folic acid", unit: "mcg",
+		ul: []float64{0, 0, 300, 400, 600, 800, 1000, 1000, 1000, 1000, 600, 800, 1000, 1000, 1000, 1000, 800, 1000, 1000, 800, 1000, 1000}},
+	435: {name: "Folate, DFE", unit: "mcg",
+		amount: []float64{65, 80, 150, 200, 300, 400, 400, 400, 400, 400, 300, 400, 400, 400, 400, 400, 600, 600, 600, 500, 500, 500}},
+}
+
+// References returns the reference intakes of every nutrient with a Daily Value or DRI for a life stage.
+// Daily Values are returned only for ages 4 and over.
+func References(ls LifeStage) ([]Reference, error) {
+	g, err := ls.group()
+	if err != nil {
+		return nil, err
+	}
+	var refs []Reference
+	for _, n := range nutrientNumbers() {
+		refs = append(refs, reference(n, g, ls.Age >= 4))
+	}
+	return refs, nil
+}
+
+// reference returns a nutrient's reference intakes for a life stage group.  A group
+// less than 0 returns only the Daily Value.
+func reference(n int, g int, dv bool) Reference {
+	r := Reference{Nutrientno: n}
+	if d, ok := DailyValues[n]; ok {
+		r.Name, r.Unit = d.Name, d.Unit
+		if dv {
+			r.DV = d.Value
+		}
+	}
+	if i, ok := intakes[n]; ok {
+		r.Name, r.Unit = i.name, i.unit
+		if g < 0 {
+			return r
+		}
+		if len(i.amount) > g {
+			if i.aiOnly || g < infants {
+				r.AI = i.amount[g]
+			} else {
+				r.RDA = i.amount[g]
+			}
+		}
+		if len(i.ul) > g {
+			r.UL = i.ul[g]
+		}
+		if len(i.cdrr) > g {
+			r.CDRR = i.cdrr[g]
+		}
+	}
+	return r
+}
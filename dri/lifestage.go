@@ -0,0 +1,98 @@
+// Package dri provides FDA Daily Values and the Dietary Reference Intakes (RDA, AI and UL)
+// for nutrients keyed by their NUT dictionary numbers
+package dri
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MALE etc define the sexes used for life stage groups
+const (
+	MALE   = "male"
+	FEMALE = "female"
+)
+
+// LifeStage describes a person for whom reference intakes apply.  Age is in years.
+type LifeStage struct {
+	Sex       string  `json:"sex,omitempty"`
+	Age       float64 `json:"age"`
+	Pregnant  bool    `json:"pregnant,omitempty"`
+	Lactating bool    `json:"lactating,omitempty"`
+}
+
+// group is a DRI life stage group.  Groups are in the same order as the values in the intake tables.
+type group struct {
+	name      string
+	sex       string
+	min, max  float64
+	pregnant  bool
+	lactating bool
+}
+
+var groups = []group{
+	{name: "Infants 0-6 mo", min: 0, max: 0.5},
+	{name: "Infants 7-12 mo", min: 0.5, max: 1},
+	{name: "Children 1-3 y", min: 1, max: 4},
+	{name: "Children 4-8 y", min: 4, max: 9},
+	{name: "Males 9-13 y", sex: MALE, min: 9, max: 14},
+	{name: "Males 14-18 y", sex: MALE, min: 14, max: 19},
+	{name: "Males 19-30 y", sex: MALE, min: 19, max: 31},
+	{name: "Males 31-50 y", sex: MALE, min: 31, max: 51},
+	{name: "Males 51-70 y", sex: MALE, min: 51, max: 71},
+	{name: "Males > 70 y", sex: MALE, min: 71, max: 200},
+	{name: "Females 9-13 y", sex: FEMALE, min: 9, max: 14},
+	{name: "Females 14-18 y", sex: FEMALE, min: 14, max: 19},
+	{name: "Females 19-30 y", sex: FEMALE, min: 19, max: 31},
+	{name: "Females 31-50 y", sex: FEMALE, min: 31, max: 51},
+	{name: "Females 51-70 y", sex: FEMALE, min: 51, max: 71},
+	{name: "Females > 70 y", sex: FEMALE, min: 71, max: 200},
+	{name: "Pregnancy 14-18 y", sex: FEMALE, min: 14, max: 19, pregnant: true},
+	{name: "Pregnancy 19-30 y", sex: FEMALE, min: 19, max: 31, pregnant: true},
+	{name: "Pregnancy 31-50 y", sex: FEMALE, min: 31, max: 51, pregnant: true},
+	{name: "Lactation 14-18 y", sex: FEMALE, min: 14, max: 19, lactating: true},
+	{name: "Lactation 19-30 y", sex: FEMALE, min: 19, max: 31, lactating: true},
+	{name: "Lactation 31-50 y", sex: FEMALE, min: 31, max: 51, lactating: true},
+}
+
+// infants are the first two groups whose reference intakes are all AI's
+const infants = 2
+
+// Validate checks a LifeStage describes a DRI life stage group
+func (ls LifeStage) Validate() error {
+	_, err := ls.group()
+	return err
+}
+
+// Group returns the name of the DRI life stage group for a LifeStage
+func (ls LifeStage) Group() string {
+	if i, err := ls.group(); err == nil {
+		return groups[i].name
+	}
+	return ""
+}
+
+// group returns the index of a LifeStage's DRI group
+func (ls LifeStage) group() (int, error) {
+	if ls.Age < 0 {
+		return 0, errors.New("age must be greater than or equal to 0")
+	}
+	if ls.Pregnant && ls.Lactating {
+		return 0, errors.New("pregnant and lactating cannot both be set")
+	}
+	if ls.Age >= 9 && ls.Sex != MALE && ls.Sex != FEMALE {
+		return 0, fmt.Errorf("sex must be '%s' or '%s' for ages 9 and over", MALE, FEMALE)
+	}
+	if (ls.Pregnant || ls.Lactating) && (ls.Sex != FEMALE || ls.Age < 14 || ls.Age >= 51) {
+		return 0, errors.New("pregnancy and lactation apply to females 14 through 50 years")
+	}
+	for i, g := range groups {
+		if ls.Age < g.min || ls.Age >= g.max || g.pregnant != ls.Pregnant || g.lactating != ls.Lactating {
+			continue
+		}
+		if g.sex == "" || g.sex == ls.Sex {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("no life stage group for age %v", ls.Age)
+}
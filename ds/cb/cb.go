@@ -5,9 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"strings"
 
 	"github.com/littlebunch/fdc-api/auth"
+	"github.com/littlebunch/fdc-api/diet"
+	"github.com/littlebunch/fdc-api/ingredient"
 	fdc "github.com/littlebunch/fdc-api/model"
 
 	gocb "gopkg.in/couchbase/gocb.v1"
@@ -107,7 +110,7 @@ func (ds *Cb) Browse(bucket string, where string, offset int64, limit int64, sor
 }
 
 // Search performs a search query, fills out a Foods slice and returns count, error
-func (ds *Cb) Search(sr fdc.SearchRequest, foods *[]interface{}) (int, error) {
+func (ds *Cb) Search(sr fdc.SearchRequest, foods *[]fdc.SearchHit) (int, error) {
 	count := 0
 	var (
 		sq     cbft.FtsQuery
@@ -125,24 +128,44 @@ func (ds *Cb) Search(sr fdc.SearchRequest, foods *[]interface{}) (int, error) {
 	default:
 		sq = cbft.NewMatchQuery(sr.Query).Field(sr.SearchField)
 	}
-	// add a foodgroup filter if we have one, otherwise run a standard search
+	// add a foodgroup filter if we have one
 	if sr.FoodGroup != "" {
-		result, err = ds.Conn.ExecuteSearchQuery(gocb.NewSearchQuery(sr.IndexName, cbft.NewConjunctionQuery(sq, cbft.NewMatchQuery(sr.FoodGroup).Field("foodGroup.description"))).Limit(int(sr.Max)).Skip(sr.Page).Fields("*"))
+		sq = cbft.NewConjunctionQuery(sq, cbft.NewMatchQuery(sr.FoodGroup).Field("foodGroup.description"))
+	}
+	// add a data source filter if we have one
+	if sr.DataSource != "" {
+		var dt fdc.DocType
+		src := cbft.NewDisjunctionQuery()
+		for _, s := range dt.ToDataSources(sr.DataSource) {
+			src.Or(cbft.NewMatchQuery(s).Field("dataSource"))
+		}
+		sq = cbft.NewConjunctionQuery(sq, src)
+	}
+	// nutrient values are in NUTDATA documents, ingredient lists and diets aren't in the search
+	// index and allergens are matched with regular expressions so run the search from N1QL
+	if len(sr.Nutrients) > 0 || len(sr.ExcludeAllergens) > 0 || sr.FirstIngredient != "" || sr.Ingredient != "" || len(sr.Diets) > 0 {
+		return ds.searchN1ql(sr, sq, foods)
+	}
+	query := gocb.NewSearchQuery(sr.IndexName, sq).Limit(int(sr.Max)).Skip(sr.Page).Fields("*").Sort(searchSort(sr.Sort, sr.Order))
+	// highlight the searched field or all fields if none was specified
+	if sr.SearchField != "" {
+		query = query.Highlight(gocb.DefaultHighlightStyle, sr.SearchField)
 	} else {
-		result, err = ds.Conn.ExecuteSearchQuery(gocb.NewSearchQuery(sr.IndexName, sq).Limit(int(sr.Max)).Skip(sr.Page).Fields("*"))
+		query = query.Highlight(gocb.DefaultHighlightStyle)
 	}
+	result, err = ds.Conn.ExecuteSearchQuery(query)
 	if err != nil {
 		return 0, err
 	}
 	count = result.TotalHits()
-	f := fdc.FoodMeta{}
 	for _, r := range result.Hits() {
+		f := fdc.SearchHit{Score: r.Score, Highlights: r.Fragments}
 		jrow, err := json.Marshal(&r.Fields)
 
 		if err != nil {
 			return 0, err
 		}
-		if err = json.Unmarshal(jrow, &f); err != nil {
+		if err = json.Unmarshal(jrow, &f.FoodMeta); err != nil {
 			return 0, err
 		}
 		*foods = append(*foods, f)
@@ -151,8 +174,123 @@ func (ds *Cb) Search(sr fdc.SearchRequest, foods *[]interface{}) (int, error) {
 	return count, nil
 }
 
+// searchN1ql runs a search query as a N1QL SEARCH() predicate constrained by the values
+// of one or more nutrients, excluded allergens, ingredients and diets, fills out a Foods slice and returns count, error
+func (ds *Cb) searchN1ql(sr fdc.SearchRequest, sq cbft.FtsQuery, foods *[]fdc.SearchHit) (int, error) {
+	count := 0
+	highlight := map[string]interface{}{}
+	if sr.SearchField != "" {
+		highlight["fields"] = []string{sr.SearchField}
+	}
+	fts, err := json.Marshal(map[string]interface{}{"query": sq, "highlight": highlight})
+	if err != nil {
+		return 0, err
+	}
+	where := fmt.Sprintf("f.type=\"FOOD\" AND SEARCH(f, %s, {\"index\":\"%s\"})", fts, sr.IndexName)
+	for _, n := range sr.Nutrients {
+		v := fmt.Sprintf("(SELECT RAW n.valuePer100UnitServing FROM %s n USE KEYS meta(f).id || \"_%d\")[0]", ds.Conn.Name(), n.Nutrient)
+		if n.ValueGTE != nil {
+			where += fmt.Sprintf(" AND %s >= %f", v, *n.ValueGTE)
+		}
+		if n.ValueLTE != nil {
+			where += fmt.Sprintf(" AND %s <= %f", v, *n.ValueLTE)
+		}
+	}
+	where += AllergenFilter("f", sr.ExcludeAllergens)
+	where += IngredientFilter("f", sr.FirstIngredient, sr.Ingredient)
+	where += DietFilter("f", sr.Diets)
+	rows, err := ds.Conn.ExecuteN1qlQuery(gocb.NewN1qlQuery(fmt.Sprintf("SELECT RAW COUNT(*) FROM %s f WHERE %s", ds.Conn.Name(), where)), nil)
+	if err != nil {
+		return 0, err
+	}
+	if err = rows.One(&count); err != nil || count == 0 {
+		return 0, err
+	}
+	sort := "SEARCH_SCORE()"
+	switch sr.Sort {
+	case fdc.DESCRIPTION:
+		sort = "f.foodDescription"
+	case fdc.COMPANY:
+		sort = "f.company"
+	case fdc.PUBLICATIONDATE:
+		sort = "f.publicationDateTime"
+	}
+	n1ql := fmt.Sprintf("SELECT f.fdcId,f.upc,f.foodDescription,f.ingredients,f.dataSource,f.company,f.type,SEARCH_SCORE() AS score,SEARCH_META().fragments AS highlights FROM %s f WHERE %s ORDER BY %s %s OFFSET %d LIMIT %d", ds.Conn.Name(), where, sort, sr.Order, sr.Page, sr.Max)
+	if rows, err = ds.Conn.ExecuteN1qlQuery(gocb.NewN1qlQuery(n1ql), nil); err != nil {
+		return 0, err
+	}
+	var f fdc.SearchHit
+	for rows.Next(&f) {
+		*foods = append(*foods, f)
+		f = fdc.SearchHit{}
+	}
+	if err = rows.Close(); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// field returns a document field qualified by an alias or unqualified if the alias is empty
+func field(alias string, name string) string {
+	if alias == "" {
+		return name
+	}
+	return alias + "." + name
+}
+
+// AllergenFilter returns a where clause excluding the FOOD documents of an alias, which may be
+// empty, declaring any of a list of allergens in their ingredients or, for foods without
+// ingredients, their description
+func AllergenFilter(alias string, allergens []string) string {
+	w := ""
+	for _, a := range allergens {
+		text := fmt.Sprintf("LOWER(IFMISSINGORNULL(NULLIF(%s, \"\"), %s))", field(alias, "ingredients"), field(alias, "foodDescription"))
+		terms, exclusions := ingredient.AllergenPatterns(a)
+		if exclusions != "" {
+			text = fmt.Sprintf("REGEXP_REPLACE(%s, \"%s\", \" \")", text, exclusions)
+		}
+		w += fmt.Sprintf(" AND NOT REGEXP_CONTAINS(%s, \"%s\")", text, terms)
+	}
+	return w
+}
+
+// IngredientFilter returns a where clause restricting the FOOD documents of an alias, which may
+// be empty, to those whose parsed ingredient list starts with an ingredient or includes an
+// ingredient at any level
+func IngredientFilter(alias string, first string, ingr string) string {
+	w := ""
+	if first = strings.ToLower(strings.Replace(first, "\"", "", -1)); first != "" {
+		w += fmt.Sprintf(" AND %s[0].name = \"%s\"", field(alias, "ingredientList"), escape(first))
+	}
+	if ingr = strings.ToLower(strings.Replace(ingr, "\"", "", -1)); ingr != "" {
+		w += fmt.Sprintf(" AND ANY i WITHIN %s SATISFIES i.name = \"%s\" END", field(alias, "ingredientList"), escape(ingr))
+	}
+	return w
+}
+
+// escape returns a value escaped for use inside a double-quoted N1QL string literal
+func escape(s string) string {
+	return strings.Replace(strings.Replace(s, "\\", "\\\\", -1), "\"", "\\\"", -1)
+}
+
+// DietFilter returns a where clause restricting the FOOD documents of an alias, which may be
+// empty, to those classified as suitable for each of a list of diets
+func DietFilter(alias string, diets []string) string {
+	w := ""
+	for _, d := range diets {
+		w += fmt.Sprintf(" AND ANY d IN %s SATISFIES d.diet = \"%s\" AND d.status = \"%s\" END", field(alias, "diets"), d, diet.SUITABLE)
+	}
+	return w
+}
+
 // NutrientReport Runs a NutrientReportRequest
 func (ds *Cb) NutrientReport(bucket string, nr fdc.NutrientReportRequest, nutrients *[]interface{}) error {
+	return ds.Query(nutrientReport(bucket, nr), nutrients)
+}
+
+// nutrientReport builds the query for a NutrientReportRequest.  Reports sorted by a score join
+// the NUTDATA documents to their foods and order on the score saved on the food.
+func nutrientReport(bucket string, nr fdc.NutrientReportRequest) string {
 	w := ""
 	qfield := ""
 	sort := "nutdata"
@@ -167,9 +305,187 @@ func (ds *Cb) NutrientReport(bucket string, nr fdc.NutrientReportRequest, nutrie
 	} else {
 		qfield = "n.valuePer100UnitServing"
 	}
-	n1ql := fmt.Sprintf("SELECT n.foodDescription,n.upc,n.fdcId,n.category,n.company,n.valuePer100UnitServing,n.unit,n.portion,n.portionValue FROM %s n USE index(%s) WHERE %s n.type=\"NUTDATA\" AND n.nutrientNumber=%d AND %s between %f AND %f OFFSET %d LIMIT %d", bucket, useIndex(sort, nr.Order), w, nr.Nutrient, qfield, nr.ValueGTE, nr.ValueLTE, nr.Page, nr.Max)
-	err := ds.Query(n1ql, nutrients)
-	return err
+	if len(nr.Nutrients) > 0 {
+		return nutrientsReport(bucket, nr, sort)
+	}
+	if nr.Mode != "" {
+		return metricReport(bucket, nr)
+	}
+	if field, ok := fdc.ScoreSorts[nr.Sort]; ok {
+		if nr.FoodGroup != "" {
+			w = fmt.Sprintf(" n.category=\"%s\" AND ", nr.FoodGroup)
+		}
+		return fmt.Sprintf("SELECT n.foodDescription,n.upc,n.fdcId,n.category,n.company,n.valuePer100UnitServing,n.unit,n.portion,n.portionValue,f.scores FROM %[1]s n USE index(%[2]s) JOIN %[1]s f ON KEYS n.fdcId WHERE %[3]s n.type=\"NUTDATA\" AND n.nutrientNumber=%[4]d AND n.valuePer100UnitServing between %[5]f AND %[6]f AND f.%[7]s IS VALUED%[11]s ORDER BY f.%[7]s %[8]s OFFSET %[9]d LIMIT %[10]d", bucket, useIndex(sort, nr.Order), w, nr.Nutrient, nr.ValueGTE, nr.ValueLTE, field, nr.Order, nr.Page, nr.Max, derivationFilter("n", nr.Derivations))
+	}
+	return fmt.Sprintf("SELECT n.foodDescription,n.upc,n.fdcId,n.category,n.company,n.valuePer100UnitServing,n.unit,n.portion,n.portionValue FROM %s n USE index(%s) WHERE %s n.type=\"NUTDATA\" AND n.nutrientNumber=%d AND %s between %f AND %f%s OFFSET %d LIMIT %d", bucket, useIndex(sort, nr.Order), w, nr.Nutrient, qfield, nr.ValueGTE, nr.ValueLTE, derivationFilter("n", nr.Derivations), nr.Page, nr.Max)
+}
+
+// metricReport builds the query for a report ranking foods on a metric derived from
+// the nutrient's value and the value of a denominator nutrient in the same food
+func metricReport(bucket string, nr fdc.NutrientReportRequest) string {
+	denominator := nr.Denominator
+	metric := "n.valuePer100UnitServing / d.valuePer100UnitServing"
+	switch nr.Mode {
+	case fdc.DENSITY:
+		denominator = fdc.ENERGY
+		metric = "n.valuePer100UnitServing / d.valuePer100UnitServing * 100"
+	case fdc.ENERGYPERCENT:
+		denominator = fdc.ENERGY
+		metric = fmt.Sprintf("n.valuePer100UnitServing * %f / d.valuePer100UnitServing * 100", fdc.AtwaterFactors[nr.Nutrient])
+	}
+	w := ""
+	if nr.FoodGroup != "" {
+		w = fmt.Sprintf(" n.category=\"%s\" AND ", nr.FoodGroup)
+	}
+	df := derivationFilter("n", nr.Derivations) + derivationFilter("d", nr.Derivations)
+	return fmt.Sprintf("SELECT n.foodDescription,n.upc,n.fdcId,n.category,n.company,n.valuePer100UnitServing,n.unit,d.valuePer100UnitServing AS denominatorValue,d.unit AS denominatorUnit,%[1]s AS metric FROM %[2]s n USE index(%[3]s) JOIN %[2]s d ON KEYS n.fdcId || \"_%[4]d\" WHERE %[5]s n.type=\"NUTDATA\" AND n.nutrientNumber=%[6]d AND d.valuePer100UnitServing > 0 AND %[1]s between %[7]f AND %[8]f%[12]s ORDER BY metric %[9]s OFFSET %[10]d LIMIT %[11]d", metric, bucket, useIndex("nutdata", nr.Order), denominator, w, nr.Nutrient, nr.ValueGTE, nr.ValueLTE, nr.Order, nr.Page, nr.Max, df)
+}
+
+// nutrientsReport builds the query for a report on a list of nutrient constraints.
+// The sort nutrient's NUTDATA documents drive the query so the report can use the same
+// indexes as a single nutrient report.  The other nutrients are joined on their keys.
+func nutrientsReport(bucket string, nr fdc.NutrientReportRequest, sort string) string {
+	field := "valuePer100UnitServing"
+	if strings.HasSuffix(sort, "_portion") {
+		field = "portionValue"
+	}
+	// put the sort nutrient first
+	nf := []fdc.NutrientFilter{}
+	for _, n := range nr.Nutrients {
+		if n.Nutrient == nr.SortNutrient {
+			nf = append([]fdc.NutrientFilter{n}, nf...)
+		} else {
+			nf = append(nf, n)
+		}
+	}
+	var (
+		items, joins []string
+	)
+	where := fmt.Sprintf("n0.type=\"NUTDATA\" AND n0.nutrientNumber=%d", nf[0].Nutrient)
+	if nr.FoodGroup != "" {
+		where = fmt.Sprintf("n0.category=\"%s\" AND %s", nr.FoodGroup, where)
+	}
+	for i, n := range nf {
+		a := fmt.Sprintf("n%d", i)
+		if i > 0 {
+			joins = append(joins, fmt.Sprintf("JOIN %s %s ON KEYS n0.fdcId || \"_%d\"", bucket, a, n.Nutrient))
+		}
+		if n.ValueGTE != nil {
+			where += fmt.Sprintf(" AND %s.%s >= %f", a, field, *n.ValueGTE)
+		}
+		if n.ValueLTE != nil {
+			where += fmt.Sprintf(" AND %s.%s <= %f", a, field, *n.ValueLTE)
+		}
+		where += derivationFilter(a, nr.Derivations)
+		items = append(items, fmt.Sprintf("{\"nutrientNumber\":%[1]s.nutrientNumber,\"nutrientName\":%[1]s.nutrientName,\"valuePer100UnitServing\":%[1]s.valuePer100UnitServing,\"unit\":%[1]s.unit,\"valuePerPortion\":%[1]s.portionValue}", a))
+	}
+	// sort on a score saved on the food
+	order, scores := "n0."+field, ""
+	if sf, ok := fdc.ScoreSorts[nr.Sort]; ok {
+		joins = append(joins, fmt.Sprintf("JOIN %s f ON KEYS n0.fdcId", bucket))
+		where += fmt.Sprintf(" AND f.%s IS VALUED", sf)
+		order, scores = "f."+sf, ",f.scores"
+	}
+	return fmt.Sprintf("SELECT n0.foodDescription,n0.upc,n0.fdcId,n0.category,n0.company,n0.portion,[%s] AS nutrients%s FROM %s n0 USE index(%s) %s WHERE %s ORDER BY %s %s OFFSET %d LIMIT %d", strings.Join(items, ","), scores, bucket, useIndex(sort, nr.Order), strings.Join(joins, " "), where, order, nr.Order, nr.Page, nr.Max)
+}
+
+// derivationFilter returns the condition which leaves out the NUTDATA values of an alias whose
+// derivation matches a DerivationFilter.  Codes are trimmed and descriptions classified on their
+// first matching term as provenance.Kind does.  Values without a derivation are kept.
+func derivationFilter(alias string, df *fdc.DerivationFilter) string {
+	if df == nil || (len(df.Codes) == 0 && len(df.Terms) == 0) {
+		return ""
+	}
+	codes, _ := json.Marshal(df.Codes)
+	known, _ := json.Marshal(df.Known)
+	terms, _ := json.Marshal(df.Terms)
+	precedence, _ := json.Marshal(df.Precedence)
+	code := fmt.Sprintf("IFMISSINGORNULL(UPPER(TRIM(%s.derivation.code)), \"\")", alias)
+	desc := fmt.Sprintf("IFMISSINGORNULL(LOWER(%s.derivation.description), \"\")", alias)
+	return fmt.Sprintf(" AND (%[1]s.derivation IS MISSING OR NOT (%[2]s IN %[4]s OR (%[2]s NOT IN %[5]s AND IFMISSINGORNULL((FIRST t FOR t IN %[7]s WHEN CONTAINS(%[3]s, t) END) IN %[6]s, FALSE))))", alias, code, desc, codes, known, terms, precedence)
+}
+
+// NutrientValues fills out a slice of a nutrient's values per 100 units in ascending order
+// for the foods described in a NutrientStatsRequest
+func (ds *Cb) NutrientValues(bucket string, sr fdc.NutrientStatsRequest, values *[]float64) error {
+	var dt fdc.DocType
+	w := ""
+	params := map[string]interface{}{}
+	if sr.FoodGroup != "" {
+		w = " AND n.category=$fg"
+		params["fg"] = sr.FoodGroup
+	}
+	if sr.DataSource != "" {
+		var ors []string
+		for _, src := range dt.ToDataSources(sr.DataSource) {
+			ors = append(ors, fmt.Sprintf("n.Datasource = '%s'", src))
+		}
+		w += fmt.Sprintf(" AND ( %s )", strings.Join(ors, " OR "))
+	}
+	n1ql := fmt.Sprintf("SELECT RAW n.valuePer100UnitServing FROM %s n USE index(%s) WHERE n.type=\"NUTDATA\" AND n.nutrientNumber=%d AND n.valuePer100UnitServing IS NOT MISSING%s ORDER BY n.valuePer100UnitServing", bucket, useIndex("nutdata", "asc"), sr.Nutrient, w)
+	rows, err := ds.Conn.ExecuteN1qlQuery(gocb.NewN1qlQuery(n1ql), params)
+	if err != nil {
+		return err
+	}
+	var v float64
+	for rows.Next(&v) {
+		*values = append(*values, v)
+	}
+	return rows.Close()
+}
+
+// Similar fills out a slice of the foods whose nutrient vectors are most similar to the vector in a
+// SimilarRequest ordered from most to least similar.  The foods with vectors are found with the
+// idx_nutrient_vector index.
+func (ds *Cb) Similar(bucket string, sr fdc.SimilarRequest, foods *[]fdc.SimilarFood) error {
+	var (
+		dt    fdc.DocType
+		v     []string
+		score string
+		order = "DESC"
+	)
+	norm := 0.0
+	for _, x := range sr.Vector {
+		v = append(v, fmt.Sprintf("%g", x))
+		norm += x * x
+	}
+	q := "[" + strings.Join(v, ",") + "]"
+	if sr.Metric == fdc.EUCLIDEAN {
+		score = fmt.Sprintf("SQRT(ARRAY_SUM(ARRAY POWER(x - %s[i], 2) FOR i:x IN f.nutrientVector END))", q)
+		order = "ASC"
+	} else {
+		score = fmt.Sprintf("ARRAY_SUM(ARRAY x * %s[i] FOR i:x IN f.nutrientVector END) / (SQRT(ARRAY_SUM(ARRAY x * x FOR x IN f.nutrientVector END)) * %g)", q, math.Sqrt(norm))
+	}
+	w := ""
+	params := map[string]interface{}{"fdcId": sr.FdcID}
+	if sr.FoodGroup != "" {
+		w += " AND f.foodGroup.description=$fg"
+		params["fg"] = sr.FoodGroup
+	}
+	if sr.DataSource != "" {
+		var ors []string
+		for _, src := range dt.ToDataSources(sr.DataSource) {
+			ors = append(ors, fmt.Sprintf("f.dataSource = '%s'", src))
+		}
+		w += fmt.Sprintf(" AND ( %s )", strings.Join(ors, " OR "))
+	}
+	for _, i := range sr.Lower {
+		w += fmt.Sprintf(" AND f.nutrientVector[%d] < %g", i, sr.Vector[i])
+	}
+	for _, i := range sr.Higher {
+		w += fmt.Sprintf(" AND f.nutrientVector[%d] > %g", i, sr.Vector[i])
+	}
+	n1ql := fmt.Sprintf("SELECT f.fdcId,f.foodDescription,f.company,f.dataSource,f.foodGroup,%s AS score FROM %s f USE INDEX(idx_nutrient_vector) WHERE f.type=\"%s\" AND f.nutrientVector IS VALUED AND f.fdcId != $fdcId%s ORDER BY score %s LIMIT %d", score, bucket, dt.ToString(fdc.FOOD), w, order, sr.Max)
+	rows, err := ds.Conn.ExecuteN1qlQuery(gocb.NewN1qlQuery(n1ql), params)
+	if err != nil {
+		return err
+	}
+	var f fdc.SimilarFood
+	for rows.Next(&f) {
+		*foods = append(*foods, f)
+		f = fdc.SimilarFood{}
+	}
+	return rows.Close()
 }
 
 // Update updates an existing document in the datastore using Upsert
@@ -180,6 +496,12 @@ func (ds *Cb) Update(id string, r interface{}) error {
 
 }
 
+// UpdateField sets a single field of an existing document using the sub-document API
+func (ds *Cb) UpdateField(id string, path string, v interface{}) error {
+	_, err := ds.Conn.MutateIn(id, 0, 0).Upsert(path, v, false).Execute()
+	return err
+}
+
 // Remove removes a document in the datastore
 func (ds *Cb) Remove(id string) error {
 	_, err := ds.Conn.Remove(id, 0)
@@ -210,8 +532,14 @@ func (ds *Cb) BulkInsert(items []gocb.BulkOp) error {
 
 // Query performs an arbitrary but well-formed query
 func (ds Cb) Query(q string, f *[]interface{}) error {
+	return ds.QueryParams(q, nil, f)
+}
+
+// QueryParams performs an arbitrary but well-formed query whose $name placeholders are bound to
+// named parameters
+func (ds Cb) QueryParams(q string, params map[string]interface{}, f *[]interface{}) error {
 	query := gocb.NewN1qlQuery(q)
-	rows, err := ds.Conn.ExecuteN1qlQuery(query, nil)
+	rows, err := ds.Conn.ExecuteN1qlQuery(query, params)
 	if err == nil {
 		var row interface{}
 		for rows.Next(&row) {
@@ -249,6 +577,12 @@ func useIndex(sort string, order string) string {
 		useindex = "idx_nutdata_fg_portion_query"
 	case "nutdata_fg":
 		useindex = "idx_nutdata_fg_query"
+	case fdc.ScoreSorts[fdc.NUTRISCORE]:
+		useindex = "idx_nutriscore"
+	case fdc.ScoreSorts[fdc.HEALTHSTAR]:
+		useindex = "idx_healthstar"
+	case fdc.ScoreSorts[fdc.NRF]:
+		useindex = "idx_nrf"
 	case "fdcid":
 	default:
 		useindex = "idx_fdcId"
@@ -261,6 +595,23 @@ func useIndex(sort string, order string) string {
 	return useindex
 }
 
+// Generates the sort clause for a search.  Text fields are sorted on their
+// keyword fields so a description or company sorts as a whole rather than on
+// it's analyzed terms.  Relevance is the default.
+func searchSort(sort string, order string) interface{} {
+	desc := order == "desc"
+	switch sort {
+	case fdc.DESCRIPTION:
+		return cbft.NewSearchSortField("foodDescription_kw").Type("string").Descending(desc)
+	case fdc.COMPANY:
+		return cbft.NewSearchSortField("company_kw").Type("string").Descending(desc)
+	case fdc.PUBLICATIONDATE:
+		return cbft.NewSearchSortField("publicationDateTime").Type("date").Descending(desc)
+	default:
+		return cbft.NewSearchSortScore().Descending(order != "asc")
+	}
+}
+
 /* Possible nutrient report:
 *select  distinct meta(g).id,g.foodDescription,g.dataSource,n.nutrientNumber,n.valuePer100UnitServing from gnutdata n
 join gnutdata g on n.fdcId = meta(g).id
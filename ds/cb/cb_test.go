@@ -0,0 +1,103 @@
+package cb
+
+import (
+	"strings"
+	"testing"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+func TestUseIndex(t *testing.T) {
+	tests := []struct {
+		sort, order, index string
+	}{
+		{fdc.ScoreSorts[fdc.NUTRISCORE], "asc", "idx_nutriscore_asc"},
+		{fdc.ScoreSorts[fdc.HEALTHSTAR], "desc", "idx_healthstar_desc"},
+		{fdc.ScoreSorts[fdc.NRF], "desc", "idx_nrf_desc"},
+		{"nutdata_fg_portion", "asc", "idx_nutdata_fg_portion_query_asc"},
+		{"fdcId", "asc", "idx_fdcId_asc"},
+	}
+	for _, tt := range tests {
+		if i := useIndex(tt.sort, tt.order); i != tt.index {
+			t.Errorf("%s %s uses %s SB %s", tt.sort, tt.order, i, tt.index)
+		}
+	}
+}
+
+func TestNutrientReportScoreSort(t *testing.T) {
+	nr := fdc.NutrientReportRequest{Nutrient: 307, ValueLTE: 100, Sort: fdc.NUTRISCORE, Order: "asc", FoodGroup: "Snacks", Max: 50}
+	q := nutrientReport("gnutdata", nr)
+	for _, want := range []string{"USE index(idx_nutdata_fg_query_asc)", "JOIN gnutdata f ON KEYS n.fdcId", "n.category=\"Snacks\"",
+		"f.scores.nutriScore.score IS VALUED", "ORDER BY f.scores.nutriScore.score asc", "LIMIT 50"} {
+		if !strings.Contains(q, want) {
+			t.Errorf("score sorted report is missing %s: %s", want, q)
+		}
+	}
+	nr.Sort, nr.FoodGroup = "", ""
+	if q := nutrientReport("gnutdata", nr); strings.Contains(q, "JOIN") || !strings.Contains(q, "USE index(idx_nutdata_query_asc)") {
+		t.Errorf("value report is %s", q)
+	}
+}
+
+func TestNutrientsReportScoreSort(t *testing.T) {
+	gte := 10.0
+	nr := fdc.NutrientReportRequest{Nutrients: []fdc.NutrientFilter{{Nutrient: 203, ValueGTE: &gte}, {Nutrient: 307}}, SortNutrient: 307,
+		Sort: fdc.HEALTHSTAR, Order: "desc", Max: 10}
+	q := nutrientReport("gnutdata", nr)
+	for _, want := range []string{"n0.nutrientNumber=307", "JOIN gnutdata n1 ON KEYS n0.fdcId || \"_203\"", "n1.valuePer100UnitServing >= 10",
+		"JOIN gnutdata f ON KEYS n0.fdcId", "ORDER BY f.scores.healthStarRating.stars desc", ",f.scores FROM"} {
+		if !strings.Contains(q, want) {
+			t.Errorf("score sorted nutrients report is missing %s: %s", want, q)
+		}
+	}
+}
+
+func TestAllergenFilter(t *testing.T) {
+	w := AllergenFilter("f", []string{"milk"})
+	if !strings.Contains(w, "LOWER(IFMISSINGORNULL(NULLIF(f.ingredients, \"\"), f.foodDescription))") || !strings.Contains(w, "REGEXP_REPLACE(") ||
+		!strings.HasPrefix(w, " AND NOT REGEXP_CONTAINS(") {
+		t.Errorf("milk filter is %s", w)
+	}
+	if w := AllergenFilter("", []string{"peanuts", "soy"}); strings.Count(w, "NOT REGEXP_CONTAINS") != 2 || strings.Contains(w, "f.") {
+		t.Errorf("unqualified peanut and soy filter is %s", w)
+	}
+	if w := AllergenFilter("f", nil); w != "" {
+		t.Errorf("Expecting no filter without allergens %s", w)
+	}
+}
+
+func TestIngredientFilter(t *testing.T) {
+	w := IngredientFilter("f", "Sugar", "\"Salt\"")
+	if !strings.Contains(w, " AND f.ingredientList[0].name = \"sugar\"") || !strings.Contains(w, "ANY i WITHIN f.ingredientList SATISFIES i.name = \"salt\" END") {
+		t.Errorf("ingredient filter is %s", w)
+	}
+	if w := IngredientFilter("", "", "salt"); w != " AND ANY i WITHIN ingredientList SATISFIES i.name = \"salt\" END" {
+		t.Errorf("unqualified ingredient filter is %s", w)
+	}
+	// a trailing backslash must not escape the closing quote
+	if w := IngredientFilter("", "a\\", " OR 1=1 OR \"\""); w != " AND ingredientList[0].name = \"a\\\\\" AND ANY i WITHIN ingredientList SATISFIES i.name = \" or 1=1 or \" END" {
+		t.Errorf("backslash ingredient filter is %s", w)
+	}
+}
+
+func TestDietFilter(t *testing.T) {
+	if w := DietFilter("f", []string{"vegan"}); w != " AND ANY d IN f.diets SATISFIES d.diet = \"vegan\" AND d.status = \"suitable\" END" {
+		t.Errorf("vegan filter is %s", w)
+	}
+	if w := DietFilter("", []string{"vegan", "keto"}); strings.Count(w, "ANY d IN diets") != 2 {
+		t.Errorf("unqualified vegan and keto filter is %s", w)
+	}
+}
+
+func TestDerivationFilter(t *testing.T) {
+	df := &fdc.DerivationFilter{Codes: []string{"NC"}, Known: []string{"A", "NC"}, Terms: []string{"calculated"}, Precedence: []string{"label", "calculated"}}
+	w := derivationFilter("n", df)
+	for _, want := range []string{"UPPER(TRIM(n.derivation.code))", "FIRST t FOR t IN [\"label\",\"calculated\"] WHEN CONTAINS(", "END) IN [\"calculated\"], FALSE)"} {
+		if !strings.Contains(w, want) {
+			t.Errorf("derivation filter is missing %s: %s", want, w)
+		}
+	}
+	if w := derivationFilter("n", &fdc.DerivationFilter{}); w != "" {
+		t.Errorf("Expecting no filter without codes or terms %s", w)
+	}
+}
@@ -13,12 +13,16 @@ type DataSource interface {
 	ConnectDs(cs fdc.Config) error
 	Get(q string, f interface{}) error
 	Query(q string, f *[]interface{}) error
+	QueryParams(q string, params map[string]interface{}, f *[]interface{}) error
 	Counts(bucket string, doctype string, c *[]interface{}) error
 	GetDictionary(dsname string, doctype string, offset int64, limit int64) ([]interface{}, error)
 	Browse(bucket string, where string, offset int64, limit int64, sort string, order string) ([]interface{}, error)
-	Search(sr fdc.SearchRequest, foods *[]interface{}) (int, error)
+	Search(sr fdc.SearchRequest, foods *[]fdc.SearchHit) (int, error)
 	NutrientReport(bucket string, nr fdc.NutrientReportRequest, nutrients *[]interface{}) error
+	NutrientValues(bucket string, sr fdc.NutrientStatsRequest, values *[]float64) error
+	Similar(bucket string, sr fdc.SimilarRequest, foods *[]fdc.SimilarFood) error
 	Update(id string, r interface{}) error
+	UpdateField(id string, path string, v interface{}) error
 	Remove(id string) error
 	FoodExists(id string) bool
 	Bulk(n *[]fdc.NutrientData) error
@@ -0,0 +1,117 @@
+// Package fndds expands FNDDS survey foods into the input foods they're made from
+package fndds
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+// MaxDepth is the deepest level of composite input foods expanded
+const MaxDepth = 5
+
+// Resolver returns the food an input food's SR code or FNDDS food code refers to and it's NUTDATA.
+// Found is false if there is no such food.
+type Resolver func(srcode int) (f fdc.Food, nd []fdc.NutrientData, found bool, err error)
+
+// Expand breaks an FNDDS food down into it's input foods, recursively for inputs which have their
+// own input foods, with each input's contribution to 100 g of the food.  Contributions are limited
+// to a list of nutrients if one is given.
+func Expand(f fdc.Food, nd []fdc.NutrientData, resolve Resolver, nutrients []int) (fdc.FoodBreakdown, error) {
+	fb := fdc.FoodBreakdown{FdcID: f.FdcID, Description: f.Description, InputFoods: []fdc.InputFoodExpansion{}}
+	if len(f.InputFoods) == 0 {
+		return fb, errors.New("the food has no input foods")
+	}
+	want := map[int]bool{}
+	for _, n := range nutrients {
+		want[n] = true
+	}
+	var err error
+	if fb.InputFoods, fb.InputWeight, err = expand(f, 100, resolve, want, map[string]bool{f.FdcID: true}, 1); err != nil {
+		return fb, err
+	}
+	contributed := fdc.NutrientValues{}
+	for _, in := range fb.InputFoods {
+		for _, n := range in.Nutrients {
+			contributed[n.Nutrientno] += n.Value
+		}
+	}
+	for _, n := range nd {
+		if len(want) > 0 && !want[n.Nutrientno] {
+			continue
+		}
+		c := fdc.NutrientContribution{Nutrientno: n.Nutrientno, Nutrient: n.Nutrient, Unit: n.Unit, Reported: n.Value, Contributed: round(contributed[n.Nutrientno])}
+		if n.Value != 0 {
+			p := math.Round(c.Contributed/n.Value*1000) / 10
+			c.Percent = &p
+		}
+		fb.Nutrients = append(fb.Nutrients, c)
+	}
+	sort.Slice(fb.Nutrients, func(i, j int) bool { return fb.Nutrients[i].Nutrientno < fb.Nutrients[j].Nutrientno })
+	return fb, nil
+}
+
+// expand resolves a food's input foods given the grams of the food in 100 g of the survey food.
+// Composites already being expanded aren't expanded again.
+func expand(f fdc.Food, grams float64, resolve Resolver, want map[int]bool, path map[string]bool, depth int) ([]fdc.InputFoodExpansion, float64, error) {
+	total := 0.0
+	for _, in := range f.InputFoods {
+		total += float64(in.Weight)
+	}
+	var inputs []fdc.InputFoodExpansion
+	for _, in := range f.InputFoods {
+		x := fdc.InputFoodExpansion{SeqNo: in.SeqNo, Description: in.Description, Amount: in.Amount, Unit: in.Unit,
+			PortionDescription: in.PortionDescription, SrCode: in.SrCode, Weight: float64(in.Weight)}
+		if total > 0 {
+			x.Per100g = round(float64(in.Weight) / total * grams)
+		}
+		if in.SrCode != 0 {
+			food, nd, found, err := resolve(in.SrCode)
+			if err != nil {
+				return nil, 0, err
+			}
+			if found {
+				x.FdcID, x.Source, x.Description = food.FdcID, food.Source, food.Description
+				for n, v := range fdc.NewNutrientValues(nd).Scale(x.Per100g) {
+					if len(want) == 0 || want[n] {
+						x.Nutrients = append(x.Nutrients, fdc.RecipeNutrient{Nutrientno: n, Nutrient: name(nd, n), Unit: unit(nd, n), Value: round(v)})
+					}
+				}
+				sort.Slice(x.Nutrients, func(i, j int) bool { return x.Nutrients[i].Nutrientno < x.Nutrients[j].Nutrientno })
+				if len(food.InputFoods) > 0 && depth < MaxDepth && !path[food.FdcID] {
+					path[food.FdcID] = true
+					if x.InputFoods, _, err = expand(food, x.Per100g, resolve, want, path, depth+1); err != nil {
+						return nil, 0, err
+					}
+					delete(path, food.FdcID)
+				}
+			}
+		}
+		inputs = append(inputs, x)
+	}
+	return inputs, total, nil
+}
+
+func name(nd []fdc.NutrientData, n int) string {
+	for _, d := range nd {
+		if d.Nutrientno == n {
+			return d.Nutrient
+		}
+	}
+	return ""
+}
+
+func unit(nd []fdc.NutrientData, n int) string {
+	for _, d := range nd {
+		if d.Nutrientno == n {
+			return d.Unit
+		}
+	}
+	return ""
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
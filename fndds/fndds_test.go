@@ -0,0 +1,42 @@
+package fndds
+
+import (
+	"testing"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+func protein(v float64) []fdc.NutrientData {
+	return []fdc.NutrientData{{Nutrientno: fdc.PROTEIN, Nutrient: "Protein", Unit: "g", Value: v}}
+}
+
+func TestExpand(t *testing.T) {
+	sandwich := fdc.Food{FdcID: "1", Description: "Egg sandwich", InputFoods: []fdc.InputFood{
+		{SeqNo: 1, Description: "Bread", SrCode: 18069, Weight: 50}, {SeqNo: 2, Description: "Scrambled egg", SrCode: 32105010, Weight: 50}}}
+	egg := fdc.Food{FdcID: "3", Description: "Egg, scrambled", Source: "FNDDS", InputFoods: []fdc.InputFood{
+		{SeqNo: 1, Description: "Egg", SrCode: 1123, Weight: 90}, {SeqNo: 2, Description: "Butter", SrCode: 1001, Weight: 10}, {SeqNo: 3, Description: "Salt", SrCode: 2047, Weight: 0.3}}}
+	foods := map[int]fdc.Food{18069: {FdcID: "2", Description: "Bread, white", Source: "SR"}, 32105010: egg,
+		1123: {FdcID: "4", Description: "Egg, whole, raw"}, 1001: {FdcID: "5", Description: "Butter"}}
+	nutdata := map[int][]fdc.NutrientData{18069: protein(8), 32105010: protein(10), 1123: protein(12.6), 1001: protein(0.9)}
+	resolve := func(srcode int) (fdc.Food, []fdc.NutrientData, bool, error) {
+		f, ok := foods[srcode]
+		return f, nutdata[srcode], ok, nil
+	}
+	fb, err := Expand(sandwich, protein(9.5), resolve, nil)
+	if err != nil || fb.InputWeight != 100 || len(fb.InputFoods) != 2 {
+		t.Fatalf("breakdown is %+v %v", fb, err)
+	}
+	bread, scrambled := fb.InputFoods[0], fb.InputFoods[1]
+	if bread.FdcID != "2" || bread.Per100g != 50 || bread.Nutrients[0].Value != 4 {
+		t.Errorf("bread is %+v", bread)
+	}
+	if len(scrambled.InputFoods) != 3 || scrambled.InputFoods[0].Per100g != 44.865 || scrambled.InputFoods[2].FdcID != "" {
+		t.Errorf("scrambled egg is %+v", scrambled)
+	}
+	if p := fb.Nutrients[0]; p.Contributed != 9 || *p.Percent != 94.7 {
+		t.Errorf("protein is %+v", p)
+	}
+	if _, err = Expand(fdc.Food{FdcID: "2"}, nil, resolve, nil); err == nil {
+		t.Errorf("Expecting an error for a food without input foods")
+	}
+}
@@ -0,0 +1,174 @@
+package ingredient
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+// MILK etc are the major food allergens named in FALCPA and the FASTER Act
+const (
+	MILK      = "milk"
+	EGG       = "egg"
+	FISH      = "fish"
+	SHELLFISH = "shellfish"
+	TREENUTS  = "treeNuts"
+	PEANUTS   = "peanuts"
+	WHEAT     = "wheat"
+	SOY       = "soy"
+	SESAME    = "sesame"
+)
+
+// allergen lists the terms, including derivatives, which declare an allergen and the phrases
+// which contain a term but don't declare the allergen, e.g. "cocoa butter" or "coconut milk"
+type allergen struct {
+	terms      []string
+	exclusions []string
+}
+
+var allergens = map[string]allergen{
+	MILK: {
+		terms: []string{"milk", "milks", "milkfat", "butter", "butterfat", "buttermilk", "cream", "cheese", "cheeses",
+			"casein", "caseinate", "caseinates", "sodium caseinate", "calcium caseinate", "whey", "lactose", "lactalbumin",
+			"lactoglobulin", "ghee", "yogurt", "yoghurt", "curds?", "kefir", "nonfat dry milk", "half and half"},
+		exclusions: []string{"cocoa butter", "shea butter", "peanut butter", "nut butter", "almond butter", "cashew butter",
+			"apple butter", "seed butter", "coconut milk", "coconut cream", "almond milk", "oat milk", "soy milk", "soymilk",
+			"rice milk", "cashew milk", "cream of tartar", "cream of coconut"},
+	},
+	EGG: {
+		terms: []string{"eggs?", "egg whites?", "egg yolks?", "albumin", "albumen", "ovalbumin", "ovomucoid", "lysozyme",
+			"mayonnaise", "meringue"},
+	},
+	FISH: {
+		terms: []string{"fish", "anchov(y|ies)", "cod", "salmon", "tuna", "tilapia", "pollock", "haddock", "halibut",
+			"sardines?", "trout", "catfish", "mackerel", "herring", "flounder", "swordfish", "snapper", "mahi mahi", "pike", "perch"},
+	},
+	SHELLFISH: {
+		terms: []string{"shellfish", "shrimps?", "prawns?", "crabs?", "crabmeat", "lobsters?", "crayfish", "crawfish",
+			"langoustines?", "krill"},
+	},
+	TREENUTS: {
+		terms: []string{"tree nuts?", "almonds?", "brazil nuts?", "cashews?", "chestnuts?", "hazelnuts?", "filberts?",
+			"macadamias?", "macadamia nuts?", "pecans?", "pine nuts?", "pistachios?", "walnuts?", "marzipan", "praline"},
+	},
+	PEANUTS: {
+		terms: []string{"peanuts?", "groundnuts?", "arachis"},
+	},
+	WHEAT: {
+		terms: []string{"wheat", "semolina", "durum", "spelt", "farina", "farro", "kamut", "einkorn", "emmer", "triticale",
+			"bulgur", "couscous", "seitan", "enriched flour", "bleached flour", "unbleached flour", "all-purpose flour",
+			"bread flour", "graham flour"},
+	},
+	SOY: {
+		terms: []string{"soy", "soya", "soybeans?", "soymilk", "edamame", "tofu", "tempeh", "miso", "tamari", "shoyu"},
+	},
+	SESAME: {
+		terms: []string{"sesame", "tahini", "benne", "gingelly"},
+	},
+}
+
+// advisory matches precautionary statements such as "may contain ..." or "processed in a
+// facility that also processes ..." up to the end of the sentence
+var advisory = regexp.MustCompile(`(may (also )?contain|(processed|manufactured|produced|made|packaged|packed) (in|on) (a )?(shared )?(facility|plant|equipment|line))[^.;]*`)
+
+type compiled struct {
+	terms      *regexp.Regexp
+	exclusions *regexp.Regexp
+}
+
+var patterns = map[string]compiled{}
+
+func init() {
+	for a := range allergens {
+		terms, exclusions := AllergenPatterns(a)
+		c := compiled{terms: regexp.MustCompile(terms)}
+		if exclusions != "" {
+			c.exclusions = regexp.MustCompile(exclusions)
+		}
+		patterns[a] = c
+	}
+}
+
+// Allergens returns the names of the major food allergens in sorted order
+func Allergens() []string {
+	var a []string
+	for n := range allergens {
+		a = append(a, n)
+	}
+	sort.Strings(a)
+	return a
+}
+
+// AllergenPatterns returns the regular expressions matching the terms which declare an allergen
+// and the phrases to remove from text before matching them.  The expressions have no escapes
+// so they may also be used in datastore queries on lower case text.
+func AllergenPatterns(a string) (string, string) {
+	al, ok := allergens[a]
+	if !ok {
+		return "", ""
+	}
+	terms := fmt.Sprintf("(^|[^a-z])(%s)([^a-z]|$)", strings.Join(al.terms, "|"))
+	exclusions := ""
+	if len(al.exclusions) > 0 {
+		exclusions = fmt.Sprintf("(%s)", strings.Join(al.exclusions, "|"))
+	}
+	return terms, exclusions
+}
+
+// ValidAllergens returns an error for any name which isn't a major food allergen
+func ValidAllergens(names []string) error {
+	for _, n := range names {
+		if _, ok := allergens[n]; !ok {
+			return fmt.Errorf("Unrecognized allergen %s.  Must be one of %s", n, strings.Join(Allergens(), ", "))
+		}
+	}
+	return nil
+}
+
+// DetectAllergens finds the major food allergens declared by an ingredient statement.  Allergens
+// found only in precautionary statements such as "may contain" or "processed in a facility that
+// also processes" are returned as MayContain.
+func DetectAllergens(text string) ([]fdc.AllergenMatch, []fdc.AllergenMatch) {
+	var contains, mayContain []fdc.AllergenMatch
+	t := strings.ToLower(text)
+	advisories := strings.Join(advisory.FindAllString(t, -1), ". ")
+	t = advisory.ReplaceAllString(t, " ")
+	for _, a := range Allergens() {
+		if m := match(a, t); m != nil {
+			contains = append(contains, *m)
+		} else if m := match(a, advisories); m != nil {
+			mayContain = append(mayContain, *m)
+		}
+	}
+	return contains, mayContain
+}
+
+// returns the distinct terms in text declaring an allergen or nil if there are none
+func match(a string, text string) *fdc.AllergenMatch {
+	p := patterns[a]
+	if p.exclusions != nil {
+		text = p.exclusions.ReplaceAllString(text, " ")
+	}
+	found := map[string]bool{}
+	var terms []string
+	// continue each search from the end of the term rather than the match so the separator
+	// after a term can also precede the next one
+	for i := 0; i < len(text); {
+		m := p.terms.FindStringSubmatchIndex(text[i:])
+		if m == nil {
+			break
+		}
+		if t := text[i+m[4] : i+m[5]]; !found[t] {
+			found[t] = true
+			terms = append(terms, t)
+		}
+		i += m[5]
+	}
+	if len(terms) == 0 {
+		return nil
+	}
+	return &fdc.AllergenMatch{Allergen: a, Terms: terms}
+}
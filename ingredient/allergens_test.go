@@ -0,0 +1,34 @@
+package ingredient
+
+import (
+	"reflect"
+	"testing"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+func TestDetectAllergens(t *testing.T) {
+	contains, mayContain := DetectAllergens("ENRICHED FLOUR (WHEAT FLOUR, NIACIN), SUGAR, COCOA BUTTER, WHEY, SODIUM CASEINATE, SOY LECITHIN, EGGPLANT, PEANUT BUTTER. MAY CONTAIN ALMONDS AND MILK. PROCESSED IN A FACILITY THAT ALSO PROCESSES SESAME.")
+	want := []fdc.AllergenMatch{
+		{Allergen: MILK, Terms: []string{"whey", "sodium caseinate"}},
+		{Allergen: PEANUTS, Terms: []string{"peanut"}},
+		{Allergen: SOY, Terms: []string{"soy"}},
+		{Allergen: WHEAT, Terms: []string{"enriched flour", "wheat"}},
+	}
+	if !reflect.DeepEqual(contains, want) {
+		t.Errorf("Contains %v SB %v", contains, want)
+	}
+	want = []fdc.AllergenMatch{
+		{Allergen: SESAME, Terms: []string{"sesame"}},
+		{Allergen: TREENUTS, Terms: []string{"almonds"}},
+	}
+	if !reflect.DeepEqual(mayContain, want) {
+		t.Errorf("MayContain %v SB %v", mayContain, want)
+	}
+	if contains, _ := DetectAllergens("Coconut milk, cream of tartar, buckwheat"); len(contains) != 0 {
+		t.Errorf("Expecting no allergens, found %v", contains)
+	}
+	if err := ValidAllergens([]string{MILK, "gluten"}); err == nil {
+		t.Errorf("Expecting an error for gluten")
+	}
+}
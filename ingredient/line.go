@@ -0,0 +1,132 @@
+// Package ingredient interprets free text describing the ingredients of recipes and foods
+package ingredient
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+	"github.com/littlebunch/fdc-api/units"
+)
+
+// vulgar fractions found in recipe text
+var fractions = map[string]string{
+	"½": " 1/2", "⅓": " 1/3", "⅔": " 2/3", "¼": " 1/4", "¾": " 3/4",
+	"⅕": " 1/5", "⅖": " 2/5", "⅗": " 3/5", "⅘": " 4/5", "⅙": " 1/6", "⅚": " 5/6",
+	"⅛": " 1/8", "⅜": " 3/8", "⅝": " 5/8", "⅞": " 7/8",
+}
+
+// counts are units which aren't measures but may match a food's serving descriptions
+var counts = map[string]bool{
+	"bunch": true, "can": true, "clove": true, "cloves": true, "container": true, "ear": true,
+	"envelope": true, "head": true, "jar": true, "large": true, "leaf": true, "leaves": true,
+	"link": true, "medium": true, "package": true, "packet": true, "piece": true, "pieces": true,
+	"slice": true, "slices": true, "small": true, "sprig": true, "sprigs": true, "stalk": true,
+	"stalks": true, "stick": true, "sticks": true, "whole": true,
+}
+
+// preparations are words describing how an ingredient is prepared rather than which food it is
+var preparations = map[string]bool{
+	"beaten": true, "chopped": true, "coarsely": true, "crumbled": true, "crushed": true,
+	"cubed": true, "diced": true, "divided": true, "drained": true, "finely": true, "freshly": true,
+	"grated": true, "halved": true, "julienned": true, "mashed": true, "melted": true, "minced": true,
+	"packed": true, "peeled": true, "quartered": true, "rinsed": true, "roughly": true, "shredded": true,
+	"sifted": true, "sliced": true, "softened": true, "thinly": true, "trimmed": true, "taste": true,
+	"to": true, "optional": true,
+}
+
+// stopwords are dropped from the food phrase
+var stopwords = map[string]bool{
+	"about": true, "and": true, "for": true, "into": true, "or": true, "plus": true, "the": true,
+}
+
+var (
+	quantity    = regexp.MustCompile(`^(\d+\s+\d+/\d+|\d+/\d+|\d*\.?\d+)(\s*-\s*(\d+\s+\d+/\d+|\d+/\d+|\d*\.?\d+))?\s*`)
+	parenthesis = regexp.MustCompile(`\([^)]*\)`)
+	words       = regexp.MustCompile(`[a-z0-9%]+`)
+)
+
+// ParseLine parses an ingredient line such as "2 1/2 cups chopped raw broccoli" or "150g greek
+// yogurt, nonfat" into it's amount, unit, preparation and the phrase naming the food.  Lines
+// without a quantity are taken to be one of the food and a range such as "1-2" is it's midpoint.
+func ParseLine(line string) fdc.IngredientLine {
+	il := fdc.IngredientLine{Line: line, Amount: 1}
+	s := strings.ToLower(strings.TrimSpace(line))
+	for f, r := range fractions {
+		s = strings.Replace(s, f, r, -1)
+	}
+	s = strings.TrimSpace(parenthesis.ReplaceAllString(s, " "))
+	if m := quantity.FindStringSubmatch(s); m != nil {
+		if a, err := units.ParseAmount(m[1]); err == nil {
+			il.Amount = a
+			if m[3] != "" {
+				if b, err := units.ParseAmount(m[3]); err == nil {
+					il.Amount = (a + b) / 2
+				}
+			}
+		}
+		s = s[len(m[0]):]
+	} else if strings.HasPrefix(s, "a ") || strings.HasPrefix(s, "an ") {
+		s = s[strings.Index(s, " ")+1:]
+	}
+	f := strings.Fields(s)
+	// units are one or two words, e.g. "cup" or "fl oz", and may have a trailing period or comma
+	if len(f) > 1 {
+		if u, ok := units.Lookup(strings.Trim(f[0]+" "+f[1], ".,")); ok {
+			il.Unit = u.Name
+			f = f[2:]
+		}
+	}
+	if il.Unit == "" && len(f) > 0 {
+		w := strings.Trim(f[0], ".,")
+		if u, ok := units.Lookup(w); ok {
+			il.Unit = u.Name
+			f = f[1:]
+		} else if counts[w] {
+			il.Unit = w
+			f = f[1:]
+		}
+	}
+	if len(f) > 0 && f[0] == "of" {
+		f = f[1:]
+	}
+	var food, prep []string
+	for _, w := range words.FindAllString(strings.Join(f, " "), -1) {
+		if preparations[w] {
+			prep = append(prep, w)
+		} else if !stopwords[w] {
+			food = append(food, w)
+		}
+	}
+	il.Food = strings.Join(food, " ")
+	il.Preparation = strings.Join(prep, " ")
+	return il
+}
+
+// Confidence rates how well a food matches an ingredient's food phrase from 0 to 1.  It averages
+// the share of the phrase's words found in the food's description and the food's search score
+// relative to the best score for the phrase.
+func Confidence(phrase string, description string, score float64, best float64) float64 {
+	pw := words.FindAllString(strings.ToLower(phrase), -1)
+	if len(pw) == 0 {
+		return 0
+	}
+	dw := map[string]bool{}
+	for _, w := range words.FindAllString(strings.ToLower(description), -1) {
+		dw[w] = true
+		// match plurals, e.g. "eggs" in "Egg, whole, raw"
+		dw[strings.TrimSuffix(w, "s")] = true
+	}
+	found := 0
+	for _, w := range pw {
+		if dw[w] || dw[strings.TrimSuffix(w, "s")] {
+			found++
+		}
+	}
+	c := float64(found) / float64(len(pw))
+	if best > 0 {
+		c = (c + score/best) / 2
+	}
+	return math.Round(c*1000) / 1000
+}
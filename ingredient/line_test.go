@@ -0,0 +1,42 @@
+package ingredient
+
+import (
+	"testing"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		line   string
+		amount float64
+		unit   string
+		prep   string
+		food   string
+	}{
+		{"2 1/2 cups chopped raw broccoli", 2.5, "cup", "chopped", "raw broccoli"},
+		{"150g greek yogurt, nonfat", 150, "g", "", "greek yogurt nonfat"},
+		{"½ tsp. salt", 0.5, "tsp", "", "salt"},
+		{"1 (15 oz) can black beans, drained and rinsed", 1, "can", "drained rinsed", "black beans"},
+		{"2-3 large eggs, beaten", 2.5, "large", "beaten", "eggs"},
+		{"1 fl oz lemon juice", 1, "fl oz", "", "lemon juice"},
+		{"a pinch of nutmeg", 1, "pinch", "", "nutmeg"},
+		{"pepper to taste", 1, "", "to taste", "pepper"},
+	}
+	for _, tt := range tests {
+		il := ParseLine(tt.line)
+		if il.Amount != tt.amount || il.Unit != tt.unit || il.Preparation != tt.prep || il.Food != tt.food {
+			t.Errorf("%s parsed to %f %q %q %q SB %f %q %q %q", tt.line, il.Amount, il.Unit, il.Preparation, il.Food, tt.amount, tt.unit, tt.prep, tt.food)
+		}
+	}
+}
+
+func TestConfidence(t *testing.T) {
+	if c := Confidence("raw broccoli", "Broccoli, raw", 2, 2); c != 1 {
+		t.Errorf("Confidence is %f SB 1", c)
+	}
+	if c := Confidence("eggs", "Egg, whole, raw, fresh", 1, 4); c != 0.625 {
+		t.Errorf("Confidence is %f SB 0.625", c)
+	}
+	if c := Confidence("greek yogurt", "Milk, whole", 1, 4); c != 0.125 {
+		t.Errorf("Confidence is %f SB 0.125", c)
+	}
+}
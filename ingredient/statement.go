@@ -0,0 +1,109 @@
+package ingredient
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+var (
+	// "contains 2% or less of", "less than 2% of the following" etc. introduce the minor ingredients
+	lessThan = regexp.MustCompile(`^(contains )?((\d+(\.\d+)?)% or less of|less than (\d+(\.\d+)?)% of)( each of)?( the following)?:?\s*`)
+	percent  = regexp.MustCompile(`\s*(\d+(\.\d+)?)\s*%\s*`)
+	// allergen and advisory statements, e.g. "contains: milk, soy", aren't ingredients
+	statement = regexp.MustCompile(`^(contains|allergens?|may contain|(processed|manufactured|produced|made|packaged) (in|on))[^a-z]`)
+	leading   = regexp.MustCompile(`^(ingredients?|made from)\s*:\s*`)
+	trim      = regexp.MustCompile(`^[\s*†‡.,:;]+|[\s*†‡.,:;]+$`)
+)
+
+// ParseStatement parses an ingredient statement into a list of ingredients in the order they're
+// declared.  Ingredients listed in parentheses or brackets after an ingredient are it's
+// sub-ingredients and a percentage given with an ingredient is it's Percent.  Ingredients following
+// "contains 2% or less of" have a LessThan of 2.  Allergen and advisory statements are dropped.
+func ParseStatement(text string) []fdc.IngredientItem {
+	t := leading.ReplaceAllString(strings.ToLower(strings.TrimSpace(text)), "")
+	items, _ := parseList([]rune(t), 0, 0)
+	return items
+}
+
+// list accumulates the ingredients of a list as it's parsed
+type list struct {
+	items []fdc.IngredientItem
+	less  *float64
+	skip  bool
+	name  strings.Builder
+	subs  []fdc.IngredientItem
+}
+
+// parses a list of ingredients starting at i until the closing bracket of a sub-ingredient list
+// or the end of the statement and returns the list and the position after it
+func parseList(r []rune, i int, depth int) ([]fdc.IngredientItem, int) {
+	var l list
+	for i < len(r) {
+		switch c := r[i]; c {
+		case '(', '[', '{':
+			s, j := parseList(r, i+1, depth+1)
+			l.subs = append(l.subs, s...)
+			i = j
+			continue
+		case ')', ']', '}':
+			if depth > 0 {
+				l.add(depth)
+				return l.items, i + 1
+			}
+		case ',', ';':
+			l.add(depth)
+		case '.':
+			// a period ends a section unless it's a decimal point
+			if i+1 < len(r) && r[i+1] >= '0' && r[i+1] <= '9' {
+				l.name.WriteRune(c)
+			} else {
+				l.add(depth)
+				l.less = nil
+				l.skip = false
+			}
+		default:
+			l.name.WriteRune(c)
+		}
+		i++
+	}
+	l.add(depth)
+	return l.items, i
+}
+
+// adds the ingredient parsed since the last separator to the list
+func (l *list) add(depth int) {
+	n := strings.TrimPrefix(trim.ReplaceAllString(l.name.String(), ""), "and ")
+	subs := l.subs
+	l.name.Reset()
+	l.subs = nil
+	if m := lessThan.FindStringSubmatch(n); m != nil {
+		if v, err := strconv.ParseFloat(m[3]+m[5], 64); err == nil {
+			l.less = &v
+		}
+		n = n[len(m[0]):]
+	}
+	if depth == 0 && statement.MatchString(n+" ") {
+		l.skip = true
+	}
+	if l.skip || (n == "" && len(subs) == 0) {
+		return
+	}
+	it := fdc.IngredientItem{LessThan: l.less}
+	if m := percent.FindStringSubmatch(n); m != nil {
+		if v, err := strconv.ParseFloat(m[1], 64); err == nil {
+			it.Percent = &v
+		}
+		n = percent.ReplaceAllString(n, " ")
+	}
+	// a parenthesized percentage, e.g. "sugar (12%)", belongs to the ingredient
+	if len(subs) == 1 && subs[0].Name == "" && subs[0].Percent != nil {
+		it.Percent = subs[0].Percent
+		subs = nil
+	}
+	it.Name = strings.Join(strings.Fields(trim.ReplaceAllString(n, "")), " ")
+	it.Ingredients = subs
+	l.items = append(l.items, it)
+}
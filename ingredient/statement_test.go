@@ -0,0 +1,32 @@
+package ingredient
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseStatement(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{
+			"INGREDIENTS: SUGAR, ENRICHED FLOUR (WHEAT FLOUR, NIACIN, REDUCED IRON), COCOA (12%), CONTAINS 2% OR LESS OF: SALT, SOY LECITHIN. CONTAINS: WHEAT, SOY.",
+			`[{"name":"sugar"},{"name":"enriched flour","ingredients":[{"name":"wheat flour"},{"name":"niacin"},{"name":"reduced iron"}]},{"name":"cocoa","percent":12},{"name":"salt","lessThan":2},{"name":"soy lecithin","lessThan":2}]`,
+		},
+		{
+			"Tomato puree [water, tomato paste (tomatoes)], high fructose corn syrup, 1.5% vinegar, and natural flavors",
+			`[{"name":"tomato puree","ingredients":[{"name":"water"},{"name":"tomato paste","ingredients":[{"name":"tomatoes"}]}]},{"name":"high fructose corn syrup"},{"name":"vinegar","percent":1.5},{"name":"natural flavors"}]`,
+		},
+		{
+			"Water, less than 2% of the following: salt, citric acid. May contain milk.",
+			`[{"name":"water"},{"name":"salt","lessThan":2},{"name":"citric acid","lessThan":2}]`,
+		},
+	}
+	for _, tt := range tests {
+		b, _ := json.Marshal(ParseStatement(tt.text))
+		if string(b) != tt.want {
+			t.Errorf("%s\nparsed to %s\nSB        %s", tt.text, b, tt.want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// Package label builds FDA Nutrition Facts labels from a food's nutrient data
+package label
+
+import (
+	"fmt"
+
+	"github.com/littlebunch/fdc-api/dri"
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+// Style is a Nutrition Facts label format
+type Style string
+
+// STANDARD etc define the label formats
+const (
+	STANDARD Style = "standard"
+	LINEAR   Style = "linear"
+	DUAL     Style = "dual"
+)
+
+// Line is a nutrient declared on a label
+type Line struct {
+	Name     string  `json:"name"`
+	Amount   string  `json:"amount"`
+	Value    float64 `json:"value"`
+	DV       string  `json:"dv,omitempty"`
+	Indent   int     `json:"indent"`
+	Bold     bool    `json:"bold"`
+	Vitamin  bool    `json:"vitamin"`
+	Included bool    `json:"included"`
+}
+
+// Column is the nutrient declarations for an amount of a food, e.g. per serving or per container
+type Column struct {
+	Heading  string  `json:"heading"`
+	Weight   float64 `json:"weight"`
+	Calories float64 `json:"calories"`
+	Lines    []Line  `json:"lines"`
+}
+
+// Facts is the content of a Nutrition Facts label
+type Facts struct {
+	Description          string   `json:"foodDescription"`
+	ServingSize          string   `json:"servingSize"`
+	ServingsPerContainer float64  `json:"servingsPerContainer,omitempty"`
+	Columns              []Column `json:"columns"`
+}
+
+// declaration describes how a nutrient is declared on a label and whether it's percent
+// Daily Value is declared
+type declaration struct {
+	nutrientno int
+	name       string
+	unit       string
+	indent     int
+	bold       bool
+	vitamin    bool
+	included   bool
+	dv         bool
+	round      rounder
+}
+
+// declarations lists the nutrients in label order
+var declarations = []declaration{
+	{nutrientno: 204, name: "Total Fat", unit: "g", bold: true, dv: true, round: roundFat},
+	{nutrientno: 606, name: "Saturated Fat", unit: "g", indent: 1, dv: true, round: roundFat},
+	{nutrientno: 605, name: "Trans Fat", unit: "g", indent: 1, round: roundFat},
+	{nutrientno: 601, name: "Cholesterol", unit: "mg", bold: true, dv: true, round: roundCholesterol},
+	{nutrientno: 307, name: "Sodium", unit: "mg", bold: true, dv: true, round: roundSodium},
+	{nutrientno: 205, name: "Total Carbohydrate", unit: "g", bold: true, dv: true, round: roundGrams},
+	{nutrientno: 291, name: "Dietary Fiber", unit: "g", indent: 1, dv: true, round: roundGrams},
+	{nutrientno: 269, name: "Total Sugars", unit: "g", indent: 1, round: roundGrams},
+	{nutrientno: 539, name: "Added Sugars", unit: "g", indent: 2, included: true, dv: true, round: roundGrams},
+	{nutrientno: 203, name: "Protein", unit: "g", bold: true, round: roundGrams},
+	{nutrientno: 328, name: "Vitamin D", unit: "mcg", vitamin: true, dv: true, round: roundIncrement(0.1)},
+	{nutrientno: 301, name: "Calcium", unit: "mg", vitamin: true, dv: true, round: roundIncrement(10)},
+	{nutrientno: 303, name: "Iron", unit: "mg", vitamin: true, dv: true, round: roundIncrement(0.1)},
+	{nutrientno: 306, name: "Potassium", unit: "mg", vitamin: true, dv: true, round: roundSodium},
+}
+
+// New creates the Facts for a food from it's values per 100 grams and a serving size.  The
+// DUAL style adds a column per container when servingsPerContainer is given or otherwise
+// per 100 grams.
+func New(description string, values fdc.NutrientValues, servingSize string, servingWeight float64, servingsPerContainer float64, style Style) Facts {
+	f := Facts{Description: description, ServingSize: servingSize, ServingsPerContainer: servingsPerContainer}
+	f.Columns = append(f.Columns, newColumn("Per serving", values, servingWeight))
+	if style == DUAL {
+		if servingsPerContainer > 0 {
+			f.Columns = append(f.Columns, newColumn("Per container", values, servingWeight*servingsPerContainer))
+		} else {
+			f.Columns = append(f.Columns, newColumn("Per 100g", values, 100))
+		}
+	}
+	return f
+}
+
+// newColumn declares the nutrients in an amount of food.  Nutrients without values are omitted.
+func newColumn(heading string, values fdc.NutrientValues, weight float64) Column {
+	c := Column{Heading: heading, Weight: weight}
+	if v, ok := values[fdc.ENERGY]; ok {
+		c.Calories = RoundCalories(v * weight / 100)
+	}
+	for _, d := range declarations {
+		v, ok := values[d.nutrientno]
+		if !ok {
+			continue
+		}
+		amount := v * weight / 100
+		r, text := d.round(amount, d.unit)
+		l := Line{Name: d.name, Amount: text, Value: r, Indent: d.indent, Bold: d.bold, Vitamin: d.vitamin, Included: d.included}
+		if d.dv {
+			dv := dri.DailyValues[d.nutrientno].Value
+			if d.vitamin {
+				l.DV = fmt.Sprintf("%s%%", number(RoundVitaminDV(amount/dv*100)))
+			} else {
+				l.DV = fmt.Sprintf("%s%%", number(RoundDV(r/dv*100)))
+			}
+		}
+		c.Lines = append(c.Lines, l)
+	}
+	return c
+}
@@ -0,0 +1,68 @@
+package label
+
+import (
+	"strings"
+	"testing"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+func TestRounding(t *testing.T) {
+	for v, want := range map[float64]float64{4.9: 0, 47: 45, 52: 50, 236: 240} {
+		if r := RoundCalories(v); r != want {
+			t.Errorf("RoundCalories(%f) is %f SB %f", v, r, want)
+		}
+	}
+	tests := []struct {
+		round rounder
+		v     float64
+		unit  string
+		text  string
+	}{
+		{roundFat, 0.4, "g", "0g"},
+		{roundFat, 2.3, "g", "2.5g"},
+		{roundFat, 7.6, "g", "8g"},
+		{roundCholesterol, 1.5, "mg", "0mg"},
+		{roundCholesterol, 3, "mg", "less than 5mg"},
+		{roundCholesterol, 12, "mg", "10mg"},
+		{roundSodium, 3, "mg", "0mg"},
+		{roundSodium, 67, "mg", "65mg"},
+		{roundSodium, 146, "mg", "150mg"},
+		{roundGrams, 0.3, "g", "0g"},
+		{roundGrams, 0.7, "g", "less than 1g"},
+		{roundGrams, 6.5, "g", "7g"},
+	}
+	for _, tt := range tests {
+		if _, text := tt.round(tt.v, tt.unit); text != tt.text {
+			t.Errorf("%f%s rounds to %s SB %s", tt.v, tt.unit, text, tt.text)
+		}
+	}
+	for v, want := range map[float64]float64{1.5: 0, 7: 8, 23: 25, 67: 70} {
+		if r := RoundVitaminDV(v); r != want {
+			t.Errorf("RoundVitaminDV(%f) is %f SB %f", v, r, want)
+		}
+	}
+}
+
+func TestFacts(t *testing.T) {
+	values := fdc.NutrientValues{208: 34, 204: 0.37, 307: 33, 205: 6.64, 291: 2.6, 203: 2.82, 301: 47}
+	f := New("Broccoli, raw", values, "1 cup (91g)", 91, 0, DUAL)
+	if len(f.Columns) != 2 || f.Columns[0].Calories != 30 || f.Columns[1].Calories != 35 {
+		t.Fatalf("columns are %v", f.Columns)
+	}
+	for _, l := range f.Columns[0].Lines {
+		if l.Name == "Sodium" && (l.Amount != "30mg" || l.DV != "1%") {
+			t.Errorf("sodium is %s %s SB 30mg 1%%", l.Amount, l.DV)
+		}
+	}
+	h, err := f.HTML(STANDARD)
+	if err != nil || !strings.Contains(h, "Nutrition Facts") || !strings.Contains(h, "Per 100g") {
+		t.Errorf("html label is %s %v", h, err)
+	}
+	if s := f.SVG(STANDARD); !strings.HasPrefix(s, "<svg") || !strings.Contains(s, "Total Carbohydrate 6g") {
+		t.Errorf("svg label is %s", s)
+	}
+	if s := f.linearText(); !strings.Contains(s, "Calories 30") {
+		t.Errorf("linear label is %s", s)
+	}
+}
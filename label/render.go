@@ -0,0 +1,183 @@
+package label
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"strings"
+)
+
+const footnote = "* The % Daily Value (DV) tells you how much a nutrient in a serving of food contributes to a daily diet. 2,000 calories a day is used for general nutrition advice."
+
+var htmlTemplate = template.Must(template.New("label").Funcs(template.FuncMap{
+	"number": number,
+	"indent": func(i int) int { return i * 12 },
+}).Parse(`<div class="nutrition-facts nf-{{.Style}}" style="border:1px solid #000;padding:4px;font-family:Helvetica,Arial,sans-serif;width:{{.Width}}px">
+<div style="font-size:32px;font-weight:900;border-bottom:1px solid #000">Nutrition Facts</div>
+{{with .Facts}}{{if .ServingsPerContainer}}<div>{{number .ServingsPerContainer}} servings per container</div>
+{{end}}<div style="font-weight:700;font-size:16px;border-bottom:10px solid #000">Serving size <span style="float:right">{{.ServingSize}}</span></div>
+<table style="width:100%;border-collapse:collapse;font-size:13px">
+<tr style="border-bottom:5px solid #000"><td style="font-weight:700">Amount per serving<br><span style="font-size:24px;font-weight:900">Calories</span></td>{{range .Columns}}<td style="text-align:right"><span style="font-size:11px">{{.Heading}}</span><br><span style="font-size:28px;font-weight:900">{{number .Calories}}</span></td>{{end}}</tr>
+<tr style="border-bottom:1px solid #000"><td></td>{{range .Columns}}<td style="text-align:right;font-weight:700">% Daily Value*</td>{{end}}</tr>
+{{$cols := .Columns}}{{range $i, $l := (index .Columns 0).Lines}}<tr style="border-bottom:1px solid #000"><td style="padding-left:{{indent $l.Indent}}px">{{if $l.Included}}Includes {{$l.Amount}} {{$l.Name}}{{else}}{{if $l.Bold}}<b>{{$l.Name}}</b>{{else}}{{$l.Name}}{{end}}{{if not $l.Vitamin}} {{$l.Amount}}{{end}}{{end}}</td>{{range $cols}}{{with index .Lines $i}}<td style="text-align:right">{{if .Vitamin}}{{.Amount}} {{end}}{{if .DV}}<b>{{.DV}}</b>{{end}}</td>{{end}}{{end}}</tr>
+{{end}}</table>
+<div style="font-size:10px;border-top:5px solid #000">{{$.Footnote}}</div>{{end}}
+</div>`))
+
+var linearTemplate = template.Must(template.New("linear").Parse(`<p class="nutrition-facts nf-linear" style="border:1px solid #000;padding:4px;font-family:Helvetica,Arial,sans-serif;font-size:12px"><b style="font-size:16px">Nutrition Facts</b> {{.}}</p>`))
+
+// HTML renders the Facts as an HTML Nutrition Facts label in a style
+func (f Facts) HTML(style Style) (string, error) {
+	var b bytes.Buffer
+	if style == LINEAR {
+		err := linearTemplate.Execute(&b, f.linearText())
+		return b.String(), err
+	}
+	width := 280 + 90*(len(f.Columns)-1)
+	err := htmlTemplate.Execute(&b, struct {
+		Facts    Facts
+		Style    Style
+		Width    int
+		Footnote string
+	}{f, style, width, footnote})
+	return b.String(), err
+}
+
+// SVG renders the Facts as an SVG Nutrition Facts label in a style
+func (f Facts) SVG(style Style) string {
+	if style == LINEAR {
+		return f.linearSVG()
+	}
+	const (
+		lineHeight = 18
+		valueWidth = 90
+	)
+	width := 280 + valueWidth*(len(f.Columns)-1)
+	var b strings.Builder
+	y := 36
+	text := func(x int, y int, size int, weight string, anchor string, s string) {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="%d" font-weight="%s" text-anchor="%s">%s</text>`+"\n", x, y, size, weight, anchor, html.EscapeString(s))
+	}
+	rule := func(y int, h int) {
+		fmt.Fprintf(&b, `<rect x="6" y="%d" width="%d" height="%d"/>`+"\n", y, width-12, h)
+	}
+	colX := func(i int) int { return width - 8 - valueWidth*(len(f.Columns)-1-i) }
+	text(8, y, 30, "900", "start", "Nutrition Facts")
+	y += 8
+	rule(y, 1)
+	if f.ServingsPerContainer > 0 {
+		y += lineHeight
+		text(8, y, 13, "normal", "start", fmt.Sprintf("%s servings per container", number(f.ServingsPerContainer)))
+	}
+	y += lineHeight
+	text(8, y, 15, "700", "start", "Serving size")
+	text(width-8, y, 15, "700", "end", f.ServingSize)
+	y += 6
+	rule(y, 10)
+	y += 10 + lineHeight
+	text(8, y, 12, "700", "start", "Amount per serving")
+	for i, c := range f.Columns {
+		if len(f.Columns) > 1 {
+			text(colX(i), y, 11, "normal", "end", c.Heading)
+		}
+	}
+	y += 26
+	text(8, y, 24, "900", "start", "Calories")
+	for i, c := range f.Columns {
+		text(colX(i), y, 28, "900", "end", number(c.Calories))
+	}
+	y += 6
+	rule(y, 5)
+	y += 5 + 15
+	for i := range f.Columns {
+		text(colX(i), y, 11, "700", "end", "% Daily Value*")
+	}
+	for i, l := range f.Columns[0].Lines {
+		y += 4
+		rule(y, 1)
+		y += lineHeight - 4
+		name := l.Name
+		weight := "normal"
+		if l.Bold {
+			weight = "700"
+		}
+		if l.Included {
+			name = fmt.Sprintf("Includes %s %s", l.Amount, l.Name)
+		} else if !l.Vitamin {
+			name = fmt.Sprintf("%s %s", l.Name, l.Amount)
+		}
+		text(8+12*l.Indent, y, 13, weight, "start", name)
+		for j, c := range f.Columns {
+			v := c.Lines[i].DV
+			if l.Vitamin {
+				v = strings.TrimSpace(c.Lines[i].Amount + " " + v)
+			}
+			text(colX(j), y, 13, "700", "end", v)
+		}
+	}
+	y += 4
+	rule(y, 5)
+	for _, s := range wrap(footnote, 7*width/40) {
+		y += 12
+		text(8, y, 10, "normal", "start", s)
+	}
+	y += 8
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="%[2]d" viewBox="0 0 %[1]d %[2]d" font-family="Helvetica,Arial,sans-serif"><rect x="0.5" y="0.5" width="%[3]d" height="%[4]d" fill="#fff" stroke="#000"/>`+"\n%[5]s</svg>", width, y, width-1, y-1, b.String())
+}
+
+// linearText lists the label's declarations in a single paragraph for the linear format
+func (f Facts) linearText() string {
+	c := f.Columns[0]
+	parts := []string{}
+	if f.ServingsPerContainer > 0 {
+		parts = append(parts, fmt.Sprintf("Servings: %s", number(f.ServingsPerContainer)))
+	}
+	parts = append(parts, fmt.Sprintf("Serv. size: %s", f.ServingSize), fmt.Sprintf("Amount per serving: Calories %s", number(c.Calories)))
+	for _, l := range c.Lines {
+		s := fmt.Sprintf("%s %s", l.Name, l.Amount)
+		if l.Included {
+			s = fmt.Sprintf("Incl. %s %s", l.Amount, l.Name)
+		}
+		if l.DV != "" {
+			s += fmt.Sprintf(" (%s DV)", l.DV)
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, ", ") + "."
+}
+
+func (f Facts) linearSVG() string {
+	const width = 480
+	var b strings.Builder
+	y := 4
+	for i, s := range wrap("Nutrition Facts "+f.linearText(), 78) {
+		y += 16
+		weight := "normal"
+		if i == 0 {
+			weight = "700"
+		}
+		fmt.Fprintf(&b, `<text x="8" y="%d" font-size="12" font-weight="%s">%s</text>`+"\n", y, weight, html.EscapeString(s))
+	}
+	y += 8
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="%[2]d" viewBox="0 0 %[1]d %[2]d" font-family="Helvetica,Arial,sans-serif"><rect x="0.5" y="0.5" width="%[3]d" height="%[4]d" fill="#fff" stroke="#000"/>`+"\n%[5]s</svg>", width, y, width-1, y-1, b.String())
+}
+
+// wrap breaks text into lines of at most n characters
+func wrap(s string, n int) []string {
+	var (
+		lines []string
+		line  string
+	)
+	for _, w := range strings.Fields(s) {
+		if line != "" && len(line)+len(w)+1 > n {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += w
+	}
+	return append(lines, line)
+}
@@ -0,0 +1,121 @@
+package label
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// rounder rounds a nutrient amount per FDA labeling rules and returns the rounded amount and
+// the text declared on the label
+type rounder func(v float64, unit string) (float64, string)
+
+// roundTo rounds v to the nearest increment
+func roundTo(v float64, increment float64) float64 {
+	return math.Round(v/increment) * increment
+}
+
+// RoundCalories rounds calories to 0 below 5, the nearest 5 up to 50 and the nearest 10 above 50
+func RoundCalories(v float64) float64 {
+	switch {
+	case v < 5:
+		return 0
+	case v <= 50:
+		return roundTo(v, 5)
+	default:
+		return roundTo(v, 10)
+	}
+}
+
+// roundFat rounds total, saturated and trans fat to 0 below 0.5 g, the nearest 0.5 g below 5 g
+// and the nearest 1 g above that
+func roundFat(v float64, unit string) (float64, string) {
+	switch {
+	case v < 0.5:
+		v = 0
+	case v < 5:
+		v = roundTo(v, 0.5)
+	default:
+		v = roundTo(v, 1)
+	}
+	return v, amountText(v, unit)
+}
+
+// roundCholesterol rounds cholesterol to 0 below 2 mg, declares less than 5 mg from 2 to 5 mg
+// and rounds to the nearest 5 mg above that
+func roundCholesterol(v float64, unit string) (float64, string) {
+	switch {
+	case v < 2:
+		return 0, amountText(0, unit)
+	case v <= 5:
+		return v, "less than " + amountText(5, unit)
+	default:
+		v = roundTo(v, 5)
+		return v, amountText(v, unit)
+	}
+}
+
+// roundSodium rounds sodium and potassium to 0 below 5 mg, the nearest 5 mg up to 140 mg and the
+// nearest 10 mg above that
+func roundSodium(v float64, unit string) (float64, string) {
+	switch {
+	case v < 5:
+		v = 0
+	case v <= 140:
+		v = roundTo(v, 5)
+	default:
+		v = roundTo(v, 10)
+	}
+	return v, amountText(v, unit)
+}
+
+// roundGrams rounds carbohydrates, fiber, sugars and protein to 0 below 0.5 g, declares less than
+// 1 g below 1 g and rounds to the nearest 1 g above that
+func roundGrams(v float64, unit string) (float64, string) {
+	switch {
+	case v < 0.5:
+		return 0, amountText(0, unit)
+	case v < 1:
+		return v, "less than " + amountText(1, unit)
+	default:
+		v = roundTo(v, 1)
+		return v, amountText(v, unit)
+	}
+}
+
+// roundIncrement returns a rounder for vitamins and minerals which are declared to an increment
+func roundIncrement(increment float64) rounder {
+	return func(v float64, unit string) (float64, string) {
+		v = roundTo(v, increment)
+		return v, amountText(v, unit)
+	}
+}
+
+// RoundDV rounds the percent Daily Value of a macronutrient to the nearest 1 percent
+func RoundDV(pct float64) float64 {
+	return math.Round(pct)
+}
+
+// RoundVitaminDV rounds the percent Daily Value of a vitamin or mineral to 0 below 2 percent,
+// the nearest 2 percent up to 10, the nearest 5 percent up to 50 and the nearest 10 percent above that
+func RoundVitaminDV(pct float64) float64 {
+	switch {
+	case pct < 2:
+		return 0
+	case pct <= 10:
+		return roundTo(pct, 2)
+	case pct <= 50:
+		return roundTo(pct, 5)
+	default:
+		return roundTo(pct, 10)
+	}
+}
+
+// amountText formats an amount without trailing zeros, e.g. 2.5g or 140mg
+func amountText(v float64, unit string) string {
+	return fmt.Sprintf("%s%s", number(v), unit)
+}
+
+func number(v float64) string {
+	return strconv.FormatFloat(math.Round(v*10)/10, 'f', -1, 64)
+}
@@ -0,0 +1,178 @@
+// Package mealplan assembles days of meals from recipes and foods which meet daily nutrient targets
+package mealplan
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+// RECIPE etc are the types of meal items
+const (
+	RECIPE = "recipe"
+	FOOD   = "food"
+)
+
+// tries is the number of random combinations of items tried for a day
+const tries = 200
+
+// repeatPenalty is added to a day's score for each item also eaten the day before
+const repeatPenalty = 0.05
+
+// Item is a recipe or food which can be a meal with it's nutrients in a serving
+type Item struct {
+	Type        string
+	ID          string
+	Description string
+	Portion     string
+	Weight      float64
+	Nutrients   []fdc.RecipeNutrient
+}
+
+// Generate plans a number of days of meals.  Each day avoids the items of the day before when it can.
+func Generate(items []Item, days int, meals int, targets []fdc.NutrientTarget, r *rand.Rand) ([]fdc.MealPlanDay, error) {
+	var plan []fdc.MealPlanDay
+	var previous fdc.MealPlanDay
+	for d := 1; d <= days; d++ {
+		day, err := Day(items, meals, targets, previous, r)
+		if err != nil {
+			return nil, err
+		}
+		plan = append(plan, day)
+		previous = day
+	}
+	return plan, nil
+}
+
+// Day picks the meals of the day after previous, one item each, from random combinations of items.  Servings of every item are
+// scaled together to meet the energy target and rounded to halves.  The combination whose totals
+// fall closest to the targets wins.
+func Day(items []Item, meals int, targets []fdc.NutrientTarget, previous fdc.MealPlanDay, r *rand.Rand) (fdc.MealPlanDay, error) {
+	var best fdc.MealPlanDay
+	if len(items) == 0 {
+		return best, errors.New("no recipes or foods meet the meal plan's exclusions")
+	}
+	energy, ok := energyTarget(targets)
+	if !ok {
+		return best, errors.New("a meal plan requires an energy target")
+	}
+	eaten := map[string]bool{}
+	for _, m := range previous.Meals {
+		eaten[m.Type+m.ID] = true
+	}
+	bestScore := math.Inf(1)
+	for t := 0; t < tries; t++ {
+		picks := pick(len(items), meals, r)
+		kcal := 0.0
+		for _, i := range picks {
+			kcal += values(items[i])[fdc.ENERGY]
+		}
+		if kcal <= 0 {
+			continue
+		}
+		day := fdc.MealPlanDay{Day: previous.Day + 1}
+		for _, i := range picks {
+			s := math.Max(math.Round(energy/kcal*2)/2, 0.5)
+			day.Meals = append(day.Meals, fdc.MealItem{Type: items[i].Type, ID: items[i].ID, Description: items[i].Description,
+				Portion: items[i].Portion, Servings: s, Weight: math.Round(s * items[i].Weight)})
+		}
+		day.Totals = Totals(items, day.Meals, targets)
+		score := Score(day.Totals)
+		for _, m := range day.Meals {
+			if eaten[m.Type+m.ID] {
+				score += repeatPenalty
+			}
+		}
+		if score < bestScore {
+			best, bestScore = day, score
+		}
+	}
+	if bestScore == math.Inf(1) {
+		return best, errors.New("no recipes or foods have energy values")
+	}
+	return best, nil
+}
+
+// Totals sums the nutrients of a day's meals for each target and for energy
+func Totals(items []Item, meals []fdc.MealItem, targets []fdc.NutrientTarget) []fdc.OptimizedNutrient {
+	total := fdc.NutrientValues{}
+	meta := map[int]fdc.RecipeNutrient{}
+	for _, m := range meals {
+		for _, it := range items {
+			if it.Type != m.Type || it.ID != m.ID {
+				continue
+			}
+			for _, n := range it.Nutrients {
+				total[n.Nutrientno] += n.Value * m.Servings
+				meta[n.Nutrientno] = n
+			}
+			break
+		}
+	}
+	var totals []fdc.OptimizedNutrient
+	for _, t := range targets {
+		totals = append(totals, fdc.OptimizedNutrient{Nutrientno: t.Nutrientno, Nutrient: meta[t.Nutrientno].Nutrient, Unit: meta[t.Nutrientno].Unit,
+			Value: math.Round(total[t.Nutrientno]*100) / 100, Min: t.Min, Max: t.Max})
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Nutrientno < totals[j].Nutrientno })
+	return totals
+}
+
+// Score sums the fractions by which totals fall below their minimums or exceed their maximums
+func Score(totals []fdc.OptimizedNutrient) float64 {
+	s := 0.0
+	for _, t := range totals {
+		if t.Min != nil && t.Value < *t.Min && *t.Min > 0 {
+			s += (*t.Min - t.Value) / *t.Min
+		}
+		if t.Max != nil && t.Value > *t.Max && *t.Max > 0 {
+			s += (t.Value - *t.Max) / *t.Max
+		}
+	}
+	return s
+}
+
+// energyTarget returns the energy a day's meals are scaled to: the middle of the energy target's
+// range or it's min or max
+func energyTarget(targets []fdc.NutrientTarget) (float64, bool) {
+	for _, t := range targets {
+		if t.Nutrientno != fdc.ENERGY {
+			continue
+		}
+		switch {
+		case t.Min != nil && t.Max != nil:
+			return (*t.Min + *t.Max) / 2, true
+		case t.Min != nil:
+			return *t.Min, true
+		case t.Max != nil:
+			return *t.Max, true
+		}
+	}
+	return 0, false
+}
+
+// pick returns the positions of the items for a day's meals.  Items aren't repeated in a day
+// unless there are fewer items than meals.
+func pick(n int, meals int, r *rand.Rand) []int {
+	var p []int
+	for len(p) < meals {
+		perm := r.Perm(n)
+		if left := meals - len(p); left < n {
+			perm = perm[:left]
+		}
+		p = append(p, perm...)
+	}
+	return p
+}
+
+// values returns an item's nutrients in a serving
+func values(it Item) fdc.NutrientValues {
+	v := fdc.NutrientValues{}
+	for _, n := range it.Nutrients {
+		v[n.Nutrientno] = n.Value
+	}
+	return v
+}
@@ -0,0 +1,45 @@
+package mealplan
+
+import (
+	"math/rand"
+	"testing"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+func item(id string, kcal float64, protein float64) Item {
+	return Item{Type: RECIPE, ID: id, Description: id, Weight: 100, Nutrients: []fdc.RecipeNutrient{
+		{Nutrientno: fdc.ENERGY, Nutrient: "Energy", Unit: "kcal", Value: kcal},
+		{Nutrientno: fdc.PROTEIN, Nutrient: "Protein", Unit: "g", Value: protein}}}
+}
+
+func TestGenerate(t *testing.T) {
+	items := []Item{item("oats", 300, 10), item("chili", 500, 30), item("salad", 200, 5), item("salmon", 400, 35), item("pasta", 600, 20)}
+	kcal, min, max, protein := 2000.0, 1900.0, 2100.0, 80.0
+	targets := []fdc.NutrientTarget{{Nutrientno: fdc.ENERGY, Min: &min, Max: &max}, {Nutrientno: fdc.PROTEIN, Min: &protein}}
+	plan, err := Generate(items, 7, 3, targets, rand.New(rand.NewSource(1)))
+	if err != nil || len(plan) != 7 {
+		t.Fatalf("plan is %v %v", plan, err)
+	}
+	for _, d := range plan {
+		seen := map[string]bool{}
+		for _, m := range d.Meals {
+			if seen[m.ID] {
+				t.Errorf("day %d repeats %s", d.Day, m.ID)
+			}
+			seen[m.ID] = true
+		}
+		if len(d.Meals) != 3 || d.Totals[1].Nutrientno != fdc.ENERGY || d.Totals[1].Value < kcal*0.8 || d.Totals[1].Value > kcal*1.2 {
+			t.Errorf("day %d is %+v", d.Day, d)
+		}
+		if d.Totals[0].Value < protein {
+			t.Errorf("day %d has %.0f g of protein SB >= %.0f", d.Day, d.Totals[0].Value, protein)
+		}
+	}
+	if plan[6].Day != 7 {
+		t.Errorf("last day is %d SB 7", plan[6].Day)
+	}
+	if _, err = Generate(items, 1, 3, targets[1:], rand.New(rand.NewSource(1))); err == nil {
+		t.Errorf("Expecting an error without an energy target")
+	}
+}
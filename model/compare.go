@@ -0,0 +1,33 @@
+// Package fdc describes food products data model
+package fdc
+
+// ComparisonFood is a column of a food comparison.  Weight is the grams of the food the column's
+// values are for.
+type ComparisonFood struct {
+	FdcID        string  `json:"fdcId"`
+	Description  string  `json:"foodDescription"`
+	Manufacturer string  `json:"company,omitempty"`
+	Portion      string  `json:"portion,omitempty"`
+	Weight       float64 `json:"weight"`
+}
+
+// ComparisonRow is a nutrient's values for each food in the order of the comparison's foods.  A
+// food without a value for the nutrient has a null value.  Highest and Lowest are the fdcIds of the
+// foods with the highest and lowest values and Difference is the difference between them.
+type ComparisonRow struct {
+	Nutrientno int        `json:"nutrientNumber"`
+	Nutrient   string     `json:"nutrientName"`
+	Unit       string     `json:"unit"`
+	Values     []*float64 `json:"values"`
+	Highest    []string   `json:"highest,omitempty"`
+	Lowest     []string   `json:"lowest,omitempty"`
+	Difference float64    `json:"difference"`
+}
+
+// Comparison is a table of nutrients as rows and foods as columns normalized to a basis of per
+// 100 g, per serving or per 100 kcal
+type Comparison struct {
+	Basis     string           `json:"basis"`
+	Foods     []ComparisonFood `json:"foods"`
+	Nutrients []ComparisonRow  `json:"nutrients"`
+}
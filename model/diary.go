@@ -0,0 +1,61 @@
+// Package fdc describes food products data model
+package fdc
+
+import (
+	"time"
+)
+
+// DiaryEntry is an amount of a food a user ate.  The unit may be a unit of mass or volume or one
+// of the food's serving descriptions.  Date is the day of the timestamp in it's own time zone.
+// Weight and Nutrients cache the amount in grams and it's nutrients as of when it was logged.
+type DiaryEntry struct {
+	ID          string           `json:"entryId"`
+	Owner       string           `json:"owner"`
+	Type        string           `json:"type"`
+	FdcID       string           `json:"fdcId,omitempty"`
+	Upc         string           `json:"upc,omitempty"`
+	Description string           `json:"foodDescription"`
+	Amount      float64          `json:"amount" binding:"required"`
+	Unit        string           `json:"unit" binding:"required"`
+	Meal        string           `json:"meal,omitempty"`
+	Timestamp   time.Time        `json:"timestamp"`
+	Date        string           `json:"date"`
+	Weight      float64          `json:"weight"`
+	Nutrients   []RecipeNutrient `json:"nutrients"`
+}
+
+// DiaryNutrient is the total of a nutrient over a period, it's average per logged day and the
+// average's percent of the Daily Value
+type DiaryNutrient struct {
+	Nutrientno int      `json:"nutrientNumber"`
+	Nutrient   string   `json:"nutrientName"`
+	Unit       string   `json:"unit"`
+	Value      float64  `json:"value"`
+	Average    float64  `json:"dailyAverage"`
+	PercentDV  *float64 `json:"percentDV,omitempty"`
+}
+
+// DiaryPeriod is the nutrient totals of a day or week of diary entries
+type DiaryPeriod struct {
+	Start     string          `json:"start"`
+	End       string          `json:"end"`
+	Entries   int             `json:"entries"`
+	Days      int             `json:"daysLogged"`
+	Nutrients []DiaryNutrient `json:"nutrients"`
+}
+
+// DiaryPoint is a day's total of a nutrient and it's average over the 7 days ending with it
+type DiaryPoint struct {
+	Date          string  `json:"date"`
+	Value         float64 `json:"value"`
+	MovingAverage float64 `json:"movingAverage"`
+}
+
+// DiaryTrend is a nutrient's daily totals over logged days and the least squares change per day
+type DiaryTrend struct {
+	Nutrientno int          `json:"nutrientNumber"`
+	Nutrient   string       `json:"nutrientName"`
+	Unit       string       `json:"unit"`
+	Days       []DiaryPoint `json:"days"`
+	Slope      float64      `json:"slopePerDay"`
+}
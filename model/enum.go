@@ -19,6 +19,34 @@ const (
 	REGEX    = "REGEX"
 )
 
+// RELEVANCE etc defines values for Search sorts
+const (
+	RELEVANCE       = "relevance"
+	DESCRIPTION     = "foodDescription"
+	COMPANY         = "company"
+	PUBLICATIONDATE = "publicationDate"
+)
+
+// NUTRISCORE etc defines values for nutrient profile score sorts
+const (
+	NUTRISCORE = "nutriScore"
+	HEALTHSTAR = "healthStarRating"
+	NRF        = "nrf"
+)
+
+// DENSITY etc defines values for Nutrient Report modes
+const (
+	DENSITY       = "density"
+	RATIO         = "ratio"
+	ENERGYPERCENT = "energyPercent"
+)
+
+// COSINE etc defines values for similarity metrics
+const (
+	COSINE    = "cosine"
+	EUCLIDEAN = "euclidean"
+)
+
 // SR is standard reference
 const (
 	SR DocType = iota
@@ -33,6 +61,10 @@ const (
 	FOOD
 	USER
 	NUTDATA
+	RECIPE
+	QUALITY
+	MEALPLAN
+	DIARY
 )
 
 //ToDocType -- convert a string to a DocType
@@ -62,6 +94,14 @@ func (dt *DocType) ToDocType(t string) DocType {
 		return FOOD
 	case "USER":
 		return USER
+	case "RECIPE":
+		return RECIPE
+	case "QUALITY":
+		return QUALITY
+	case "MEALPLAN":
+		return MEALPLAN
+	case "DIARY":
+		return DIARY
 	default:
 		return 999
 	}
@@ -94,7 +134,24 @@ func (dt *DocType) ToString(t DocType) string {
 		return "FOOD"
 	case USER:
 		return "USER"
+	case RECIPE:
+		return "RECIPE"
+	case QUALITY:
+		return "QUALITY"
+	case MEALPLAN:
+		return "MEALPLAN"
+	case DIARY:
+		return "DIARY"
 	default:
 		return ""
 	}
 }
+
+//ToDataSources -- convert a data source to the dataSource values found on it's documents.
+//Branded foods (BFPD) are loaded as either LI or GDSN
+func (dt *DocType) ToDataSources(t string) []string {
+	if t == dt.ToString(BFPD) {
+		return []string{"LI", "GDSN"}
+	}
+	return []string{t}
+}
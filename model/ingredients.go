@@ -0,0 +1,36 @@
+// Package fdc describes food products data model
+package fdc
+
+// AllergenMatch is a major food allergen and the terms in an ingredient statement which declare it
+type AllergenMatch struct {
+	Allergen string   `json:"allergen"`
+	Terms    []string `json:"terms"`
+}
+
+// AllergenReport is returned from the food allergens endpoint.  MayContain lists allergens found
+// only in precautionary statements, e.g. "may contain" or "processed in a facility with".
+type AllergenReport struct {
+	FdcID       string          `json:"fdcId"`
+	Description string          `json:"foodDescription"`
+	Ingredients string          `json:"ingredients,omitempty"`
+	Contains    []AllergenMatch `json:"contains"`
+	MayContain  []AllergenMatch `json:"mayContain"`
+}
+
+// IngredientItem is an ingredient parsed from an ingredient statement with it's sub-ingredients.
+// Percent is the ingredient's share of the food when declared and LessThan is the percent in a
+// "contains 2% or less of" declaration which includes the ingredient.
+type IngredientItem struct {
+	Name        string           `json:"name"`
+	Percent     *float64         `json:"percent,omitempty"`
+	LessThan    *float64         `json:"lessThan,omitempty"`
+	Ingredients []IngredientItem `json:"ingredients,omitempty"`
+}
+
+// DietClass is a food's suitability for a diet or nutrient content claim with the reasons for it.
+// Status is suitable, unsuitable or unknown when the food lacks the data to decide.
+type DietClass struct {
+	Diet    string   `json:"diet"`
+	Status  string   `json:"status"`
+	Reasons []string `json:"reasons"`
+}
@@ -0,0 +1,42 @@
+// Package fdc describes food products data model
+package fdc
+
+// InputFoodExpansion is an FNDDS input food resolved to it's food.  Weight is the input's grams in
+// it's parent food and Per100g it's grams in 100 g of the expanded survey food.  Nutrients are the
+// input's contributions to 100 g of the survey food.  Inputs which are themselves composites are
+// expanded into their own InputFoods.
+type InputFoodExpansion struct {
+	SeqNo              int                  `json:"seq"`
+	Description        string               `json:"foodDescription"`
+	Amount             float32              `json:"amount"`
+	Unit               string               `json:"unit"`
+	PortionDescription string               `json:"portionDescription,omitempty"`
+	SrCode             int                  `json:"srcode,omitempty"`
+	FdcID              string               `json:"fdcId,omitempty"`
+	Source             string               `json:"dataSource,omitempty"`
+	Weight             float64              `json:"weight"`
+	Per100g            float64              `json:"per100g"`
+	Nutrients          []RecipeNutrient     `json:"nutrients,omitempty"`
+	InputFoods         []InputFoodExpansion `json:"inputfoods,omitempty"`
+}
+
+// NutrientContribution compares a survey food's reported nutrient value per 100 g to the sum of
+// it's input foods' contributions
+type NutrientContribution struct {
+	Nutrientno  int      `json:"nutrientNumber"`
+	Nutrient    string   `json:"nutrientName"`
+	Unit        string   `json:"unit"`
+	Reported    float64  `json:"reported"`
+	Contributed float64  `json:"contributed"`
+	Percent     *float64 `json:"percentContributed,omitempty"`
+}
+
+// FoodBreakdown is an FNDDS food expanded into it's input foods.  InputWeight is the sum of the
+// weights of it's input foods.
+type FoodBreakdown struct {
+	FdcID       string                 `json:"fdcId"`
+	Description string                 `json:"foodDescription"`
+	InputWeight float64                `json:"inputWeight"`
+	Nutrients   []NutrientContribution `json:"nutrients"`
+	InputFoods  []InputFoodExpansion   `json:"inputfoods"`
+}
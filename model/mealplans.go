@@ -0,0 +1,48 @@
+// Package fdc describes food products data model
+package fdc
+
+import (
+	"time"
+)
+
+// MealPlanRequest describes a meal plan to generate.  Targets are daily amounts of nutrients and
+// must include energy.  Meals are drawn from the owner's saved Recipes, all of them if none are
+// listed, and the Foods listed by fdcId which don't contain any of the ExcludeAllergens and are
+// suitable for each of the Diets.
+type MealPlanRequest struct {
+	Days             int              `json:"days"`
+	MealsPerDay      int              `json:"mealsPerDay"`
+	Targets          []NutrientTarget `json:"targets" binding:"required"`
+	ExcludeAllergens []string         `json:"excludeAllergens,omitempty"`
+	Diets            []string         `json:"diets,omitempty"`
+	Recipes          []string         `json:"recipes,omitempty"`
+	Foods            []string         `json:"foods,omitempty"`
+}
+
+// MealItem is a number of servings of a recipe or food in a meal
+type MealItem struct {
+	Type        string  `json:"type"`
+	ID          string  `json:"id"`
+	Description string  `json:"description"`
+	Portion     string  `json:"portion,omitempty"`
+	Servings    float64 `json:"servings"`
+	Weight      float64 `json:"weight"`
+}
+
+// MealPlanDay is a day's meals and their nutrient totals compared to the plan's targets
+type MealPlanDay struct {
+	Day    int                 `json:"day"`
+	Meals  []MealItem          `json:"meals"`
+	Totals []OptimizedNutrient `json:"totals"`
+}
+
+// MealPlan is a user's saved meal plan
+type MealPlan struct {
+	ID        string    `json:"mealPlanId"`
+	Name      string    `json:"name" binding:"required"`
+	Owner     string    `json:"owner"`
+	Type      string    `json:"type"`
+	UpdatedAt time.Time `json:"lastChangeDateTime"`
+	MealPlanRequest
+	Plan []MealPlanDay `json:"plan"`
+}
@@ -0,0 +1,61 @@
+// Package fdc describes food products data model
+package fdc
+
+// PROTEIN etc are NUT dictionary numbers for nutrients used in calculations
+const (
+	PROTEIN      = 203
+	TOTALFAT     = 204
+	CARBOHYDRATE = 205
+	ENERGY       = 208
+	ALCOHOL      = 221
+	SUGARS       = 269
+	FIBER        = 291
+	SODIUM       = 307
+	VITAMINDIU   = 324
+	VITAMIND     = 328
+	ADDEDSUGARS  = 539
+	CHOLESTEROL  = 601
+	SATURATEDFAT = 606
+)
+
+// AtwaterFactors are the general kcal per gram factors for the energy yielding nutrients
+var AtwaterFactors = map[int]float64{
+	PROTEIN:      4,
+	TOTALFAT:     9,
+	CARBOHYDRATE: 4,
+	ALCOHOL:      7,
+}
+
+// NutrientValues maps nutrient numbers to a food's nutrient values
+type NutrientValues map[int]float64
+
+// NewNutrientValues creates NutrientValues per 100 units from a food's NUTDATA.  Vitamin D
+// reported only in IU is converted to mcg.
+func NewNutrientValues(nd []NutrientData) NutrientValues {
+	v := NutrientValues{}
+	for _, n := range nd {
+		v[n.Nutrientno] = n.Value
+	}
+	if _, ok := v[VITAMIND]; !ok {
+		if iu, ok := v[VITAMINDIU]; ok {
+			v[VITAMIND] = iu / 40
+		}
+	}
+	return v
+}
+
+// Scale returns the values for a weight in grams of a food whose values are per 100 grams
+func (v NutrientValues) Scale(weight float64) NutrientValues {
+	s := NutrientValues{}
+	for n, value := range v {
+		s[n] = value * weight / 100
+	}
+	return s
+}
+
+// Add adds the values of another food
+func (v NutrientValues) Add(a NutrientValues) {
+	for n, value := range a {
+		v[n] += value
+	}
+}
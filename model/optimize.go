@@ -0,0 +1,65 @@
+// Package fdc describes food products data model
+package fdc
+
+// NutrientTarget is the minimum and maximum total amount of a nutrient in an optimized diet
+type NutrientTarget struct {
+	Nutrientno int      `json:"nutrientno" binding:"required"`
+	Min        *float64 `json:"min,omitempty"`
+	Max        *float64 `json:"max,omitempty"`
+}
+
+// OptimizeFood is a food which may be included in an optimized diet.  Min and Max bound it's
+// amount in grams and Cost is it's cost per 100 g.
+type OptimizeFood struct {
+	FdcID string   `json:"fdcId"`
+	Min   float64  `json:"min,omitempty"`
+	Max   *float64 `json:"max,omitempty"`
+	Cost  *float64 `json:"cost,omitempty"`
+}
+
+// OptimizeRequest wraps a POST diet optimization.  Candidate foods are listed by id, drawn from
+// food groups or found by a search query.  MaxWeight is the most grams of any food without it's
+// own Max.  The Objective is the lowest energy or the lowest cost.  WholeServings restricts
+// amounts to whole numbers of each food's first serving with a weight.
+type OptimizeRequest struct {
+	Targets       []NutrientTarget `json:"targets"`
+	Foods         []OptimizeFood   `json:"foods,omitempty"`
+	FoodGroups    []string         `json:"foodGroups,omitempty"`
+	Query         string           `json:"q,omitempty"`
+	DataSource    string           `json:"dataSource,omitempty"`
+	MaxFoods      int              `json:"maxFoods,omitempty"`
+	MaxWeight     float64          `json:"maxWeight,omitempty"`
+	Objective     string           `json:"objective,omitempty"`
+	WholeServings bool             `json:"wholeServings,omitempty"`
+}
+
+// OptimizedFood is the amount of a food in an optimized diet
+type OptimizedFood struct {
+	FdcID       string   `json:"fdcId"`
+	Description string   `json:"foodDescription"`
+	Weight      float64  `json:"weight"`
+	Portion     string   `json:"portion,omitempty"`
+	Servings    *float64 `json:"servings,omitempty"`
+	Cost        *float64 `json:"cost,omitempty"`
+}
+
+// OptimizedNutrient is the total amount of a nutrient in an optimized diet and it's target
+type OptimizedNutrient struct {
+	Nutrientno int      `json:"nutrientNumber"`
+	Nutrient   string   `json:"nutrientName"`
+	Unit       string   `json:"unit"`
+	Value      float64  `json:"value"`
+	Min        *float64 `json:"min,omitempty"`
+	Max        *float64 `json:"max,omitempty"`
+}
+
+// OptimizeResult is an optimized diet.  Status is optimal, feasible when the solver stopped at it's
+// node or time limit with a diet which meets the targets but may not be the best, or infeasible
+// when no combination of the foods meets the targets.
+type OptimizeResult struct {
+	Status    string              `json:"status"`
+	Objective string              `json:"objective"`
+	Value     float64             `json:"value"`
+	Foods     []OptimizedFood     `json:"foods"`
+	Nutrients []OptimizedNutrient `json:"nutrients"`
+}
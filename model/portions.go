@@ -0,0 +1,48 @@
+// Package fdc describes food products data model
+package fdc
+
+// Portion is one of a food's servings with the household measure it's described in.  Measure is
+// empty for servings like "stalk" or "slice" which aren't a unit of mass or volume.  Milliliters
+// is the volume of a serving measured in a unit of volume.
+type Portion struct {
+	Description  string   `json:"servingUnit"`
+	Amount       float32  `json:"value"`
+	Weight       float32  `json:"weight"`
+	GramsPerUnit float64  `json:"gramsPerUnit"`
+	Measure      string   `json:"measure,omitempty"`
+	Kind         string   `json:"kind,omitempty"`
+	Milliliters  *float64 `json:"milliliters,omitempty"`
+	State        string   `json:"servingState,omitempty"`
+	Basis        string   `json:"nutrientBasis,omitempty"`
+	Datapoints   int32    `json:"dataPoints,omitempty"`
+}
+
+// PortionConversion is an amount of a food in a unit converted to grams.  Method is how the unit
+// was converted: one of the food's servings, a unit of mass or a unit of volume and the food's
+// density.
+type PortionConversion struct {
+	Amount float64 `json:"amount"`
+	Unit   string  `json:"unit"`
+	Weight float64 `json:"weight"`
+	Method string  `json:"method"`
+}
+
+// FoodPortions lists a food's portions, it's density in grams per milliliter when one can be
+// found and an optional conversion of an amount to grams
+type FoodPortions struct {
+	FdcID       string             `json:"fdcId"`
+	Description string             `json:"foodDescription"`
+	Density     *float64           `json:"gramsPerMilliliter,omitempty"`
+	Portions    []Portion          `json:"portions"`
+	Conversion  *PortionConversion `json:"conversion,omitempty"`
+}
+
+// MeasureUnit is a unit of mass or volume in the UNIT dictionary.  Factor is it's size in grams
+// for mass units or milliliters for volume units.
+type MeasureUnit struct {
+	Name    string   `json:"name"`
+	Kind    string   `json:"kind"`
+	Factor  float64  `json:"factor"`
+	Aliases []string `json:"aliases"`
+	Type    string   `json:"type"`
+}
@@ -0,0 +1,29 @@
+// Package fdc describes food products data model
+package fdc
+
+import (
+	"time"
+)
+
+// QualityFinding is a data quality rule a food fails.  Reported and Expected are the values which
+// were compared, e.g. reported and calculated energy.
+type QualityFinding struct {
+	Rule       string   `json:"rule"`
+	Nutrientno int      `json:"nutrientNumber,omitempty"`
+	Message    string   `json:"message"`
+	Reported   *float64 `json:"reported,omitempty"`
+	Expected   *float64 `json:"expected,omitempty"`
+}
+
+// QualityReport lists the data quality findings for a food.  Reports with findings are saved as
+// documents of type QUALITY.
+type QualityReport struct {
+	FdcID       string           `json:"fdcId"`
+	Description string           `json:"foodDescription"`
+	Source      string           `json:"dataSource"`
+	Type        string           `json:"type,omitempty"`
+	Tolerance   float64          `json:"tolerance"`
+	Energy      *float64         `json:"calculatedEnergy,omitempty"`
+	Findings    []QualityFinding `json:"findings"`
+	UpdatedAt   time.Time        `json:"lastChangeDateTime"`
+}
@@ -0,0 +1,105 @@
+// Package fdc describes food products data model
+package fdc
+
+import (
+	"time"
+)
+
+// Ingredient is an amount of a food in a recipe identified by fdcId or UPC.  The unit may be a
+// unit of mass or volume or one of the food's serving descriptions.  Retention is an optional list of
+// percent nutrient retention factors keyed by nutrient number.
+type Ingredient struct {
+	FdcID     string          `json:"fdcId,omitempty"`
+	Upc       string          `json:"upc,omitempty"`
+	Amount    float64         `json:"amount" binding:"required"`
+	Unit      string          `json:"unit" binding:"required"`
+	Retention map[int]float64 `json:"retention,omitempty"`
+}
+
+// RecipeRequest wraps a POST recipe analysis.  The cooked weight of the recipe is the YieldWeight in
+// grams if given, otherwise the raw weight of the ingredients times the YieldFactor if given.
+// Retention factors apply to every ingredient without it's own factor for the nutrient.
+type RecipeRequest struct {
+	Ingredients []Ingredient    `json:"ingredients" binding:"required"`
+	Servings    float64         `json:"servings"`
+	YieldWeight float64         `json:"yieldWeight,omitempty"`
+	YieldFactor float64         `json:"yieldFactor,omitempty"`
+	Retention   map[int]float64 `json:"retention,omitempty"`
+}
+
+// RecipeNutrient is an amount of a nutrient in a recipe
+type RecipeNutrient struct {
+	Nutrientno int     `json:"nutrientNumber"`
+	Nutrient   string  `json:"nutrientName"`
+	Unit       string  `json:"unit"`
+	Value      float64 `json:"value"`
+}
+
+// IngredientAnalysis is an ingredient's weight and contribution of nutrients to a recipe
+type IngredientAnalysis struct {
+	FdcID       string           `json:"fdcId"`
+	Description string           `json:"foodDescription"`
+	Amount      float64          `json:"amount"`
+	Unit        string           `json:"unit"`
+	Weight      float64          `json:"weight"`
+	Nutrients   []RecipeNutrient `json:"nutrients"`
+}
+
+// RecipeAnalysis is returned from the recipe endpoints
+type RecipeAnalysis struct {
+	Ingredients   []IngredientAnalysis `json:"ingredients"`
+	RawWeight     float64              `json:"rawWeight"`
+	CookedWeight  float64              `json:"cookedWeight"`
+	Servings      float64              `json:"servings"`
+	ServingWeight float64              `json:"servingWeight"`
+	Total         []RecipeNutrient     `json:"total"`
+	PerServing    []RecipeNutrient     `json:"perServing"`
+	Per100g       []RecipeNutrient     `json:"per100g"`
+}
+
+// Recipe is a user's saved recipe.  Analysis caches the recipe's nutrients as of the last write or
+// recompute and Foods lists the fdcId's of it's ingredients so recipes using a food can be found.
+// Checksum fingerprints the NUTDATA the analysis was computed from.
+type Recipe struct {
+	ID          string    `json:"recipeId"`
+	Name        string    `json:"name" binding:"required"`
+	Description string    `json:"description,omitempty"`
+	Owner       string    `json:"owner"`
+	Type        string    `json:"type"`
+	UpdatedAt   time.Time `json:"lastChangeDateTime"`
+	RecipeRequest
+	Foods    []string       `json:"foods"`
+	Checksum string         `json:"nutrientChecksum"`
+	Analysis RecipeAnalysis `json:"analysis"`
+}
+
+// IngredientParseRequest wraps a POST of ingredient lines to parse and match to foods.  Max is the
+// number of candidate foods returned for each line and DataSource optionally limits the candidates
+// to SR, FNDDS or BFPD foods.
+type IngredientParseRequest struct {
+	Lines      []string `json:"lines" binding:"required"`
+	Max        int      `json:"max"`
+	DataSource string   `json:"dataSource,omitempty"`
+}
+
+// IngredientLine is a free-text ingredient line parsed into it's amount, unit, preparation and
+// the phrase naming the food with the foods which may match it
+type IngredientLine struct {
+	Line        string                `json:"line"`
+	Amount      float64               `json:"amount"`
+	Unit        string                `json:"unit,omitempty"`
+	Preparation string                `json:"preparation,omitempty"`
+	Food        string                `json:"food"`
+	Candidates  []IngredientCandidate `json:"candidates"`
+}
+
+// IngredientCandidate is a food which may match an ingredient line ranked by a Confidence from 0 to 1.
+// Weight is the line's amount in grams when it can be converted using the food's servings.
+type IngredientCandidate struct {
+	FdcID       string   `json:"fdcId"`
+	Description string   `json:"foodDescription"`
+	Source      string   `json:"dataSource"`
+	Score       float64  `json:"score"`
+	Confidence  float64  `json:"confidence"`
+	Weight      *float64 `json:"weight,omitempty"`
+}
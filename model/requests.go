@@ -26,28 +26,62 @@ type BrowseNutrients struct {
 	Nutrients []NutrientData `json:"nutrients"`
 }
 
-// NutrientReportRequest wraps a POST nutrient report
+// NutrientReportRequest wraps a POST nutrient report.  A report is run either on a single
+// nutrient or on a list of nutrient constraints which are ANDed together.  A single nutrient
+// may also be reported by Mode as it's density per 100 kcal, it's ratio to a Denominator
+// nutrient or it's percent of energy.
 type NutrientReportRequest struct {
-	Page      int     `json:"page"`
-	Max       int     `json:"max"`
-	Nutrient  int     `json:"nutrientno" binding:"required"`
-	FoodGroup string  `json:"foodGroup,omitEmpty"`
-	Sort      string  `json:"sort,omitEmpty"`
-	Order     string  `json:"order,omitEmpty"`
-	ValueGTE  float64 `json:"valueGTE"`
-	ValueLTE  float64 `json:"valueLTE"`
+	Page         int               `json:"page"`
+	Max          int               `json:"max"`
+	Mode         string            `json:"mode,omitempty"`
+	Nutrient     int               `json:"nutrientno"`
+	Denominator  int               `json:"denominatorNutrientno,omitempty"`
+	Nutrients    []NutrientFilter  `json:"nutrients,omitempty"`
+	SortNutrient int               `json:"sortNutrientno,omitempty"`
+	FoodGroup    string            `json:"foodGroup,omitEmpty"`
+	Sort         string            `json:"sort,omitEmpty"`
+	Order        string            `json:"order,omitEmpty"`
+	ValueGTE     float64           `json:"valueGTE"`
+	ValueLTE     float64           `json:"valueLTE"`
+	Exclude      []string          `json:"excludeDerivations,omitempty"`
+	Derivations  *DerivationFilter `json:"-"`
+}
+
+// DerivationFilter selects the nutrient values to leave out of a report by their derivation.
+// Values with one of Codes are left out as are values whose code isn't one of Known and whose
+// description's first match in the Precedence list of every term is one of Terms.
+type DerivationFilter struct {
+	Codes      []string
+	Terms      []string
+	Known      []string
+	Precedence []string
 }
 
 // SearchRequest wraps a POST search
 type SearchRequest struct {
-	Query       string `json:"q" binding:"required"`
-	SearchField string `json:"searchfield,omitEmpty"`
-	Page        int    `json:"page"`
-	Max         int    `json:"max"`
-	Sort        string `json:"sort,omitEmpty"`
-	SearchType  string `json:"searchtype,omitEmpty"`
-	FoodGroup   string `json:"foodgroup,omitEmpty"`
-	IndexName   string `json:"indexname"`
+	Query            string           `json:"q" binding:"required"`
+	SearchField      string           `json:"searchfield,omitEmpty"`
+	Page             int              `json:"page"`
+	Max              int              `json:"max"`
+	Sort             string           `json:"sort,omitEmpty"`
+	Order            string           `json:"order,omitEmpty"`
+	SearchType       string           `json:"searchtype,omitEmpty"`
+	FoodGroup        string           `json:"foodgroup,omitEmpty"`
+	DataSource       string           `json:"dataSource,omitempty"`
+	Nutrients        []NutrientFilter `json:"nutrients,omitempty"`
+	ExcludeAllergens []string         `json:"excludeAllergens,omitempty"`
+	FirstIngredient  string           `json:"firstIngredient,omitempty"`
+	Ingredient       string           `json:"ingredient,omitempty"`
+	Diets            []string         `json:"diets,omitempty"`
+	IndexName        string           `json:"indexname"`
+}
+
+// NutrientFilter constrains the value per 100 units of a nutrient.  Either
+// bound may be omitted.
+type NutrientFilter struct {
+	Nutrient int      `json:"nutrientno" binding:"required"`
+	ValueGTE *float64 `json:"valueGTE,omitempty"`
+	ValueLTE *float64 `json:"valueLTE,omitempty"`
 }
 
 // SearchResult is returned from the search endpoints
@@ -57,6 +91,14 @@ type SearchResult struct {
 	Nutrients []NutrientData `json:"nutrients"`
 }
 
+// SearchHit is a food returned from the search endpoints with its relevance score
+// and the highlighted fragments of the fields which matched the query
+type SearchHit struct {
+	FoodMeta
+	Score      float64             `json:"score"`
+	Highlights map[string][]string `json:"highlights,omitempty"`
+}
+
 // SearchServings is returned from the search endpoints when format=servings
 type SearchServings struct {
 	Food     FoodMeta  `json:"foodMeta"`
@@ -69,7 +111,8 @@ type SearchNutrients struct {
 	Nutrients []NutrientData `json:"nutrients"`
 }
 
-// NutrientFoodBrowse is returned from the food nutrient endpoints
+// NutrientFoodBrowse is returned from the food nutrient endpoints.  When an amount of the
+// food is requested the AmountWeight is it's weight in grams.
 type NutrientFoodBrowse struct {
 	FdcID        string                   `json:"fdcId" binding:"required"`
 	Upc          string                   `json:"upc,omitempty"`
@@ -77,10 +120,14 @@ type NutrientFoodBrowse struct {
 	Manufacturer string                   `json:"company,omitempty"`
 	Category     string                   `json:"category,omitempty"`
 	Portion      string                   `json:"portion,omitempty"`
+	Amount       float64                  `json:"amount,omitempty"`
+	AmountUnit   string                   `json:"amountUnit,omitempty"`
+	AmountWeight float64                  `json:"amountWeight,omitempty"`
 	Nutrients    []NutrientFoodBrowseItem `json:"nutrients"`
 }
 
-// NutrientFoodBrowseItem is the list of nutrient data returned by the food nutrient endpoints
+// NutrientFoodBrowseItem is the list of nutrient data returned by the food nutrient endpoints.
+// AmountValue is the nutrient's value scaled to a requested amount of the food.
 type NutrientFoodBrowseItem struct {
 	Value        float64     `json:"valuePer100UnitServing"`
 	Unit         string      `json:"unit"  binding:"required"`
@@ -88,6 +135,19 @@ type NutrientFoodBrowseItem struct {
 	Nutrientno   int         `json:"nutrientNumber"`
 	Nutrient     string      `json:"nutrientName"`
 	PortionValue float64     `json:"valuePerPortion"`
+	AmountValue  *float64    `json:"valuePerAmount,omitempty"`
+	Provenance   *Provenance `json:"provenance,omitempty"`
+}
+
+// Provenance describes how a nutrient value was derived, the number of samples and their range
+// and a confidence tier for the value
+type Provenance struct {
+	Derivation *Derivation `json:"derivation,omitempty"`
+	Kind       string      `json:"kind"`
+	Datapoints int         `json:"datapoints"`
+	Min        *float64    `json:"min,omitempty"`
+	Max        *float64    `json:"max,omitempty"`
+	Confidence string      `json:"confidence"`
 }
 
 // NutrientReportData is an item returned in a nutrient report
@@ -101,3 +161,34 @@ type NutrientReportData struct {
 	Unit            string  `json:"unit"`
 	Type            string  `json:"type"`
 }
+
+// NutrientIntake compares an amount of a nutrient to it's Daily Value and Dietary Reference Intakes
+type NutrientIntake struct {
+	Nutrientno   int      `json:"nutrientNumber"`
+	Name         string   `json:"nutrientName"`
+	Unit         string   `json:"unit"`
+	Value        float64  `json:"value"`
+	PercentDV    *float64 `json:"percentDV,omitempty"`
+	PercentRDA   *float64 `json:"percentRDA,omitempty"`
+	PercentAI    *float64 `json:"percentAI,omitempty"`
+	PercentUL    *float64 `json:"percentUL,omitempty"`
+	ULExceeded   bool     `json:"ulExceeded"`
+	CDRRExceeded bool     `json:"cdrrExceeded,omitempty"`
+}
+
+// FoodIntake is the intake comparison for an amount of a food
+type FoodIntake struct {
+	FdcID       string           `json:"fdcId"`
+	Description string           `json:"foodDescription"`
+	ServingSize string           `json:"servingSize"`
+	Weight      float64          `json:"weight"`
+	Intakes     []NutrientIntake `json:"intakes"`
+}
+
+// IntakeReport is returned from the intake endpoints.  Total compares the sum of the foods'
+// nutrients when more than one food is requested.
+type IntakeReport struct {
+	LifeStage string           `json:"lifeStage"`
+	Foods     []FoodIntake     `json:"foods"`
+	Total     []NutrientIntake `json:"total,omitempty"`
+}
@@ -0,0 +1,34 @@
+// Package fdc describes food products data model
+package fdc
+
+// NutrientScores are a food's nutrient profile scores
+type NutrientScores struct {
+	NutriScore *NutriScore `json:"nutriScore,omitempty"`
+	HealthStar *HealthStar `json:"healthStarRating,omitempty"`
+	NRF        *float64    `json:"nrf,omitempty"`
+}
+
+// NutriScore is a food's Nutri-Score, the difference of it's negative and positive points, and
+// it's letter grade A to E.  FruitVegetables is the estimated percent of fruits, vegetables and
+// legumes used in the score.
+type NutriScore struct {
+	Score           int     `json:"score"`
+	Grade           string  `json:"grade"`
+	Negative        int     `json:"negativePoints"`
+	Positive        int     `json:"positivePoints"`
+	FruitVegetables float64 `json:"fruitVegetables"`
+}
+
+// HealthStar is a food's Health Star Rating score and it's rating from 0.5 to 5 stars
+type HealthStar struct {
+	Score int     `json:"score"`
+	Stars float64 `json:"stars"`
+}
+
+// ScoreSorts maps the score sort keys of the browse and nutrient report endpoints to the fields
+// of a food's saved scores
+var ScoreSorts = map[string]string{
+	NUTRISCORE: "scores.nutriScore.score",
+	HEALTHSTAR: "scores.healthStarRating.stars",
+	NRF:        "scores.nrf",
+}
@@ -0,0 +1,35 @@
+// Package fdc describes food products data model
+package fdc
+
+// SimilarRequest describes a search for the foods with the nutrient vectors most similar to a
+// food's.  Lower and Higher are positions in the vector of nutrients a similar food must have less
+// or more of than the food.
+type SimilarRequest struct {
+	FdcID      string    `json:"fdcId"`
+	Vector     []float64 `json:"-"`
+	Metric     string    `json:"metric"`
+	FoodGroup  string    `json:"foodGroup,omitempty"`
+	DataSource string    `json:"dataSource,omitempty"`
+	Lower      []int     `json:"-"`
+	Higher     []int     `json:"-"`
+	Max        int       `json:"max"`
+}
+
+// SimilarFood is a food found by a similarity search.  Score is the cosine similarity, higher is
+// more similar, or the Euclidean distance, lower is more similar.
+type SimilarFood struct {
+	FdcID        string     `json:"fdcId"`
+	Description  string     `json:"foodDescription"`
+	Manufacturer string     `json:"company,omitempty"`
+	Source       string     `json:"dataSource"`
+	Group        *FoodGroup `json:"foodGroup,omitempty"`
+	Score        float64    `json:"score"`
+}
+
+// SimilarResult lists the foods most similar to a food
+type SimilarResult struct {
+	Request     SimilarRequest `json:"request"`
+	Description string         `json:"foodDescription"`
+	Nutrients   []int          `json:"vectorNutrients"`
+	Items       []SimilarFood  `json:"items"`
+}
@@ -0,0 +1,111 @@
+// Package fdc describes food products data model
+package fdc
+
+import (
+	"math"
+	"sort"
+)
+
+// StatsPercentiles are the percentiles reported in NutrientStats
+var StatsPercentiles = []float64{5, 10, 25, 50, 75, 90, 95}
+
+// NutrientStatsRequest describes the foods included in a nutrient's statistics
+type NutrientStatsRequest struct {
+	Nutrient   int      `json:"nutrientno"`
+	FoodGroup  string   `json:"foodGroup,omitempty"`
+	DataSource string   `json:"dataSource,omitempty"`
+	Bins       int      `json:"bins"`
+	Value      *float64 `json:"value,omitempty"`
+}
+
+// NutrientStats describes the distribution of a nutrient's value per 100 units across foods
+type NutrientStats struct {
+	Request        NutrientStatsRequest `json:"request"`
+	Nutrient       string               `json:"nutrientName"`
+	Unit           string               `json:"unit"`
+	Count          int                  `json:"count"`
+	Min            float64              `json:"min"`
+	Max            float64              `json:"max"`
+	Mean           float64              `json:"mean"`
+	Median         float64              `json:"median"`
+	StdDev         float64              `json:"stdDev"`
+	Percentiles    []Percentile         `json:"percentiles"`
+	Histogram      []HistogramBin       `json:"histogram"`
+	PercentileRank *float64             `json:"percentileRank,omitempty"`
+}
+
+// Percentile is the value below which a percent of the values fall
+type Percentile struct {
+	Percent float64 `json:"percentile"`
+	Value   float64 `json:"value"`
+}
+
+// HistogramBin counts the values from Min up to but not including Max.  The
+// last bin of a histogram includes it's Max.
+type HistogramBin struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int     `json:"count"`
+}
+
+// Calculate fills out the statistics from a list of values sorted in ascending order.
+// The histogram has the requested number of equal width bins.  If the request has a value
+// then it's percentile rank is calculated as well.
+func (ns *NutrientStats) Calculate(values []float64) {
+	ns.Count = len(values)
+	ns.Percentiles = nil
+	ns.Histogram = nil
+	ns.PercentileRank = nil
+	if ns.Count == 0 {
+		return
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	ns.Min = values[0]
+	ns.Max = values[ns.Count-1]
+	ns.Mean = sum / float64(ns.Count)
+	ss := 0.0
+	for _, v := range values {
+		ss += (v - ns.Mean) * (v - ns.Mean)
+	}
+	ns.StdDev = math.Sqrt(ss / float64(ns.Count))
+	ns.Median = percentile(values, 50)
+	for _, p := range StatsPercentiles {
+		ns.Percentiles = append(ns.Percentiles, Percentile{Percent: p, Value: percentile(values, p)})
+	}
+	bins := ns.Request.Bins
+	if bins <= 0 || ns.Max == ns.Min {
+		bins = 1
+	}
+	width := (ns.Max - ns.Min) / float64(bins)
+	for i := 0; i < bins; i++ {
+		ns.Histogram = append(ns.Histogram, HistogramBin{Min: ns.Min + float64(i)*width, Max: ns.Min + float64(i+1)*width})
+	}
+	ns.Histogram[bins-1].Max = ns.Max
+	for _, v := range values {
+		i := bins - 1
+		if width > 0 {
+			i = int((v - ns.Min) / width)
+		}
+		if i >= bins {
+			i = bins - 1
+		}
+		ns.Histogram[i].Count++
+	}
+	if ns.Request.Value != nil {
+		// percent of values less than or equal to the requested value
+		r := float64(sort.Search(ns.Count, func(i int) bool { return values[i] > *ns.Request.Value })) / float64(ns.Count) * 100
+		ns.PercentileRank = &r
+	}
+}
+
+// percentile returns the p'th percentile of sorted values interpolating between
+// the closest ranks
+func percentile(values []float64, p float64) float64 {
+	r := p / 100 * float64(len(values)-1)
+	lo := int(math.Floor(r))
+	hi := int(math.Ceil(r))
+	return values[lo] + (values[hi]-values[lo])*(r-float64(lo))
+}
@@ -0,0 +1,29 @@
+package fdc
+
+import "testing"
+
+func TestNutrientStatsCalculate(t *testing.T) {
+	v := 4.0
+	ns := NutrientStats{Request: NutrientStatsRequest{Bins: 4, Value: &v}}
+	ns.Calculate([]float64{1, 2, 3, 4, 5, 6, 7, 8, 9})
+	if ns.Count != 9 || ns.Min != 1 || ns.Max != 9 || ns.Mean != 5 || ns.Median != 5 {
+		t.Errorf("count %d min %f max %f mean %f median %f", ns.Count, ns.Min, ns.Max, ns.Mean, ns.Median)
+	}
+	if p := ns.Percentiles[2]; p.Percent != 25 || p.Value != 3 {
+		t.Errorf("25th percentile is %f SB 3", p.Value)
+	}
+	total := 0
+	for _, b := range ns.Histogram {
+		total += b.Count
+	}
+	if len(ns.Histogram) != 4 || total != 9 || ns.Histogram[3].Count != 3 {
+		t.Errorf("histogram is %v", ns.Histogram)
+	}
+	if ns.PercentileRank == nil || *ns.PercentileRank < 44 || *ns.PercentileRank > 45 {
+		t.Errorf("percentile rank is %v SB 44.4", ns.PercentileRank)
+	}
+	ns.Calculate(nil)
+	if ns.Count != 0 || ns.Histogram != nil {
+		t.Errorf("empty values count %d histogram %v", ns.Count, ns.Histogram)
+	}
+}
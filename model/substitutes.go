@@ -0,0 +1,35 @@
+// Package fdc describes food products data model
+package fdc
+
+// NutrientDelta is the change in a nutrient per 100 g from swapping a food for a substitute
+type NutrientDelta struct {
+	Nutrientno int     `json:"nutrientNumber"`
+	Nutrient   string  `json:"nutrientName"`
+	Unit       string  `json:"unit"`
+	Original   float64 `json:"original"`
+	Value      float64 `json:"value"`
+	Delta      float64 `json:"delta"`
+	Percent    float64 `json:"percentChange"`
+}
+
+// Substitute is a food recommended in place of another.  Similarity is the cosine similarity of
+// their nutrient vectors and Improvement the mean fraction each goal is improved by.  Score, the
+// mean of the two, ranks substitutes.
+type Substitute struct {
+	FdcID        string          `json:"fdcId"`
+	Description  string          `json:"foodDescription"`
+	Manufacturer string          `json:"company,omitempty"`
+	Similarity   float64         `json:"similarity"`
+	Improvement  float64         `json:"improvement"`
+	Score        float64         `json:"score"`
+	Deltas       []NutrientDelta `json:"deltas"`
+}
+
+// SubstituteResult lists the substitutes for a food meeting a list of goals
+type SubstituteResult struct {
+	FdcID       string       `json:"fdcId"`
+	Description string       `json:"foodDescription"`
+	Category    string       `json:"category,omitempty"`
+	Goals       []string     `json:"goals"`
+	Items       []Substitute `json:"items"`
+}
@@ -0,0 +1,167 @@
+// Package optimize solves for the combination of foods which meets nutrient targets at the lowest
+// energy or cost using an embedded linear and integer programming solver
+package optimize
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/littlebunch/fdc-api/dri"
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+// ENERGY etc are the objectives a diet can be optimized for
+const (
+	ENERGY = "energy"
+	COST   = "cost"
+)
+
+// maxSolveTime limits the time spent solving one diet
+const maxSolveTime = 5 * time.Second
+
+// OPTIMAL etc are the statuses of an optimized diet
+const (
+	OPTIMAL    = "optimal"
+	FEASIBLE   = "feasible"
+	INFEASIBLE = "infeasible"
+)
+
+// Food is a candidate food, it's NUTDATA, bounds on it's amount in grams, it's cost per 100 g and
+// the label and weight of it's serving
+type Food struct {
+	Food          fdc.Food
+	Nutrients     []fdc.NutrientData
+	Min, Max      float64
+	Cost          *float64
+	Portion       string
+	ServingWeight float64
+}
+
+// Targets returns nutrient targets from a life stage's reference intakes.  The minimum is the RDA or AI
+// and the maximum the CDRR or UL.
+func Targets(ls dri.LifeStage) ([]fdc.NutrientTarget, error) {
+	refs, err := dri.References(ls)
+	if err != nil {
+		return nil, err
+	}
+	var targets []fdc.NutrientTarget
+	for _, r := range refs {
+		t := fdc.NutrientTarget{Nutrientno: r.Nutrientno}
+		if min := math.Max(r.RDA, r.AI); min > 0 {
+			t.Min = &min
+		}
+		if max := r.CDRR; max > 0 {
+			t.Max = &max
+		} else if max = r.UL; max > 0 {
+			t.Max = &max
+		}
+		if t.Min != nil || t.Max != nil {
+			targets = append(targets, t)
+		}
+	}
+	return targets, nil
+}
+
+// Optimize finds the amounts of foods which meet nutrient targets with the lowest total energy or
+// cost.  Amounts are in 100 g units or, for whole servings, numbers of servings.  Foods without a
+// value for a nutrient contribute none of it.  A whole servings search which runs out of time
+// returns the best diet found so far as feasible.
+func Optimize(foods []Food, targets []fdc.NutrientTarget, objective string, wholeServings bool) (fdc.OptimizeResult, error) {
+	r := fdc.OptimizeResult{Objective: objective, Foods: []fdc.OptimizedFood{}}
+	if len(foods) == 0 {
+		return r, fmt.Errorf("at least one food is required")
+	}
+	n := len(foods)
+	p := Problem{Objective: make([]float64, n), Lower: make([]float64, n), Upper: make([]float64, n), Integer: wholeServings, Deadline: time.Now().Add(maxSolveTime)}
+	values := make([]fdc.NutrientValues, n)
+	unit := make([]float64, n)
+	meta := map[int]fdc.NutrientData{}
+	for j, f := range foods {
+		values[j] = fdc.NewNutrientValues(f.Nutrients)
+		for _, nd := range f.Nutrients {
+			meta[nd.Nutrientno] = nd
+		}
+		unit[j] = 100
+		p.Lower[j], p.Upper[j] = f.Min/100, f.Max/100
+		if wholeServings {
+			if f.ServingWeight <= 0 {
+				return r, fmt.Errorf("%s has no serving with a weight", f.Food.FdcID)
+			}
+			unit[j] = f.ServingWeight
+			p.Lower[j], p.Upper[j] = math.Ceil(f.Min/f.ServingWeight), math.Floor(f.Max/f.ServingWeight)
+		}
+		switch objective {
+		case ENERGY:
+			p.Objective[j] = values[j][fdc.ENERGY] * unit[j] / 100
+		case COST:
+			if f.Cost == nil {
+				return r, fmt.Errorf("%s has no cost", f.Food.FdcID)
+			}
+			p.Objective[j] = *f.Cost * unit[j] / 100
+		default:
+			return r, fmt.Errorf("Unrecognized objective %s.  Must be '%s' or '%s'", objective, ENERGY, COST)
+		}
+	}
+	for _, t := range targets {
+		if t.Min != nil && t.Max != nil && *t.Min > *t.Max {
+			return r, fmt.Errorf("nutrient %d has a min greater than it's max", t.Nutrientno)
+		}
+		coef := make([]float64, n)
+		for j := range foods {
+			coef[j] = values[j][t.Nutrientno] * unit[j] / 100
+		}
+		if t.Min != nil {
+			p.Constraints = append(p.Constraints, Constraint{Coef: coef, Op: GE, RHS: *t.Min})
+		}
+		if t.Max != nil {
+			p.Constraints = append(p.Constraints, Constraint{Coef: coef, Op: LE, RHS: *t.Max})
+		}
+	}
+	x, obj, err := Solve(p)
+	if err == ErrInfeasible {
+		r.Status = INFEASIBLE
+		return r, nil
+	}
+	r.Status = OPTIMAL
+	if err == ErrNodes || err == ErrDeadline {
+		r.Status, err = FEASIBLE, nil
+	}
+	if err != nil {
+		return r, err
+	}
+	r.Value = round(obj)
+	total := fdc.NutrientValues{}
+	for j, f := range foods {
+		if x[j] < 1e-6 {
+			continue
+		}
+		g := x[j] * unit[j]
+		of := fdc.OptimizedFood{FdcID: f.Food.FdcID, Description: f.Food.Description, Weight: round(g), Portion: f.Portion}
+		if f.ServingWeight > 0 {
+			s := round(g / f.ServingWeight)
+			of.Servings = &s
+		}
+		if f.Cost != nil {
+			c := round(*f.Cost * g / 100)
+			of.Cost = &c
+		}
+		r.Foods = append(r.Foods, of)
+		total.Add(values[j].Scale(g))
+	}
+	seen := map[int]bool{}
+	for _, t := range targets {
+		seen[t.Nutrientno] = true
+		r.Nutrients = append(r.Nutrients, fdc.OptimizedNutrient{Nutrientno: t.Nutrientno, Nutrient: meta[t.Nutrientno].Nutrient, Unit: meta[t.Nutrientno].Unit, Value: round(total[t.Nutrientno]), Min: t.Min, Max: t.Max})
+	}
+	if !seen[fdc.ENERGY] {
+		r.Nutrients = append(r.Nutrients, fdc.OptimizedNutrient{Nutrientno: fdc.ENERGY, Nutrient: meta[fdc.ENERGY].Nutrient, Unit: meta[fdc.ENERGY].Unit, Value: round(total[fdc.ENERGY])})
+	}
+	sort.Slice(r.Nutrients, func(i, j int) bool { return r.Nutrients[i].Nutrientno < r.Nutrients[j].Nutrientno })
+	return r, nil
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
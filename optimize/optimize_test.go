@@ -0,0 +1,61 @@
+package optimize
+
+import (
+	"testing"
+
+	"github.com/littlebunch/fdc-api/dri"
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+func nutdata(energy, protein, fiber float64) []fdc.NutrientData {
+	return []fdc.NutrientData{{Nutrientno: fdc.ENERGY, Nutrient: "Energy", Unit: "kcal", Value: energy},
+		{Nutrientno: fdc.PROTEIN, Nutrient: "Protein", Unit: "g", Value: protein},
+		{Nutrientno: fdc.FIBER, Nutrient: "Fiber", Unit: "g", Value: fiber}}
+}
+
+func TestOptimize(t *testing.T) {
+	lentils := 1.0
+	chicken := 4.0
+	foods := []Food{
+		{Food: fdc.Food{FdcID: "1", Description: "Lentils"}, Nutrients: nutdata(116, 9, 8), Max: 500, Cost: &lentils, ServingWeight: 198},
+		{Food: fdc.Food{FdcID: "2", Description: "Chicken breast"}, Nutrients: nutdata(165, 31, 0), Max: 500, Cost: &chicken, ServingWeight: 140},
+	}
+	protein, fiber := 56.0, 20.0
+	targets := []fdc.NutrientTarget{{Nutrientno: fdc.PROTEIN, Min: &protein}, {Nutrientno: fdc.FIBER, Min: &fiber}}
+	// chicken has the least energy per gram of protein so fiber is met with lentils and the
+	// remaining protein with chicken
+	r, err := Optimize(foods, targets, ENERGY, false)
+	if err != nil || r.Status != OPTIMAL || len(r.Foods) != 2 || r.Foods[0].Weight != 250 || r.Foods[1].Weight != 108.06 {
+		t.Fatalf("optimized diet is %+v %v", r, err)
+	}
+	if r.Nutrients[0].Nutrientno != fdc.PROTEIN || r.Nutrients[0].Value != 56 || r.Nutrients[1].Nutrientno != fdc.ENERGY {
+		t.Errorf("nutrients are %+v", r.Nutrients)
+	}
+	// lentils are the cheapest protein up to their 500 g max
+	if r, _ = Optimize(foods, targets, COST, false); r.Status != OPTIMAL || r.Value != 6.42 {
+		t.Errorf("lowest cost diet is %+v", r)
+	}
+	if r, _ = Optimize(foods, targets, ENERGY, true); r.Status != OPTIMAL || *r.Foods[0].Servings != 2 || *r.Foods[1].Servings != 1 {
+		t.Errorf("whole servings diet is %+v", r)
+	}
+	protein = 500
+	if r, _ = Optimize(foods, targets, ENERGY, false); r.Status != INFEASIBLE {
+		t.Errorf("Expecting an infeasible diet, got %+v", r)
+	}
+	foods[1].Cost = nil
+	if _, err = Optimize(foods, targets, COST, false); err == nil {
+		t.Errorf("Expecting an error for a food without a cost")
+	}
+}
+
+func TestTargets(t *testing.T) {
+	targets, err := Targets(dri.LifeStage{Sex: dri.FEMALE, Age: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tg := range targets {
+		if tg.Nutrientno == fdc.SODIUM && (*tg.Min != 1500 || *tg.Max != 2300) {
+			t.Errorf("sodium target is %.0f-%.0f SB 1500-2300", *tg.Min, *tg.Max)
+		}
+	}
+}
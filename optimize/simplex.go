@@ -0,0 +1,312 @@
+package optimize
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// LE etc are the relations of a constraint's left side to it's right side
+const (
+	LE = iota
+	GE
+	EQ
+)
+
+const (
+	eps           = 1e-9
+	maxIterations = 50000
+)
+
+// maxNodes limits the subproblems solved by branch and bound
+var maxNodes = 20000
+
+// ErrInfeasible etc are returned when a linear program has no solution
+var (
+	ErrInfeasible = errors.New("no solution meets every constraint")
+	ErrUnbounded  = errors.New("the objective is unbounded")
+	ErrIterations = errors.New("the solver exceeded it's iteration limit")
+	ErrNodes      = errors.New("the solver exceeded it's branch and bound node limit")
+	ErrDeadline   = errors.New("the solver ran out of time")
+)
+
+// Constraint is a linear constraint Coef·x Op RHS
+type Constraint struct {
+	Coef []float64
+	Op   int
+	RHS  float64
+}
+
+// Problem is a linear program minimizing Objective·x subject to Constraints and Lower <= x <= Upper.
+// Upper bounds may be +Inf.  Integer restricts every variable to whole numbers.  A non-zero
+// Deadline stops the solver when it passes.
+type Problem struct {
+	Objective   []float64
+	Constraints []Constraint
+	Lower       []float64
+	Upper       []float64
+	Integer     bool
+	Deadline    time.Time
+}
+
+// Solve returns the values of the variables which minimize a problem's objective and the objective's
+// value.  Linear programs are solved with the two phase simplex method using Bland's rule and
+// integer programs with depth first branch and bound.  When branch and bound reaches it's node
+// limit or the deadline the best solution found so far is returned with ErrNodes or ErrDeadline.
+func Solve(p Problem) ([]float64, float64, error) {
+	if !p.Integer {
+		return solveLP(p, p.Lower, p.Upper)
+	}
+	var (
+		best    []float64
+		bestObj = math.Inf(1)
+		nodes   int
+	)
+	var branch func(lower, upper []float64) error
+	branch = func(lower, upper []float64) error {
+		if nodes++; nodes > maxNodes {
+			return ErrNodes
+		}
+		if !p.Deadline.IsZero() && time.Now().After(p.Deadline) {
+			return ErrDeadline
+		}
+		x, obj, err := solveLP(p, lower, upper)
+		if err == ErrInfeasible {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if obj >= bestObj-eps {
+			return nil
+		}
+		for j, v := range x {
+			if f := math.Floor(v + 1e-6); v-f > 1e-6 {
+				down := append([]float64{}, upper...)
+				down[j] = f
+				if err := branch(lower, down); err != nil {
+					return err
+				}
+				up := append([]float64{}, lower...)
+				up[j] = f + 1
+				return branch(up, upper)
+			}
+		}
+		for j := range x {
+			x[j] = math.Round(x[j])
+		}
+		best, bestObj = x, obj
+		return nil
+	}
+	err := branch(p.Lower, p.Upper)
+	if (err == ErrNodes || err == ErrDeadline) && best != nil {
+		return best, bestObj, err
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	if best == nil {
+		return nil, 0, ErrInfeasible
+	}
+	return best, bestObj, nil
+}
+
+// solveLP solves the linear relaxation of a problem with bounds on the variables.  Variables are
+// shifted by their lower bounds so they're non-negative and finite upper bounds become constraints.
+func solveLP(p Problem, lower, upper []float64) ([]float64, float64, error) {
+	n := len(p.Objective)
+	var rows []Constraint
+	for _, c := range p.Constraints {
+		rhs := c.RHS
+		for j := 0; j < n; j++ {
+			rhs -= c.Coef[j] * lower[j]
+		}
+		rows = append(rows, Constraint{Coef: c.Coef, Op: c.Op, RHS: rhs})
+	}
+	for j := 0; j < n; j++ {
+		if upper[j] < lower[j] {
+			return nil, 0, ErrInfeasible
+		}
+		if !math.IsInf(upper[j], 1) {
+			coef := make([]float64, n)
+			coef[j] = 1
+			rows = append(rows, Constraint{Coef: coef, Op: LE, RHS: upper[j] - lower[j]})
+		}
+	}
+	y, err := simplex(p.Objective, rows, p.Deadline)
+	if err != nil {
+		return nil, 0, err
+	}
+	obj := 0.0
+	for j := range y {
+		y[j] += lower[j]
+		obj += p.Objective[j] * y[j]
+	}
+	return y, obj, nil
+}
+
+// tableau is a simplex tableau.  The last column of each row is it's right hand side.
+type tableau struct {
+	t        [][]float64
+	basis    []int
+	deadline time.Time
+}
+
+// simplex minimizes c·x subject to rows and x >= 0.  Each row gets a slack, surplus or
+// artificial variable.  Phase 1 minimizes the sum of the artificial variables to find a feasible
+// basis and phase 2 minimizes c·x from it.  Pivoting stops with ErrDeadline when a non-zero
+// deadline passes.
+func simplex(c []float64, rows []Constraint, deadline time.Time) ([]float64, error) {
+	n, m := len(c), len(rows)
+	slacks, artificials := 0, 0
+	for i := range rows {
+		if rows[i].RHS < 0 {
+			coef := make([]float64, n)
+			for j, v := range rows[i].Coef {
+				coef[j] = -v
+			}
+			op := rows[i].Op
+			if op == LE {
+				op = GE
+			} else if op == GE {
+				op = LE
+			}
+			rows[i] = Constraint{Coef: coef, Op: op, RHS: -rows[i].RHS}
+		}
+		if rows[i].Op != EQ {
+			slacks++
+		}
+		if rows[i].Op != LE {
+			artificials++
+		}
+	}
+	cols := n + slacks + artificials
+	tb := tableau{t: make([][]float64, m), basis: make([]int, m), deadline: deadline}
+	s, a := n, n+slacks
+	for i, r := range rows {
+		tb.t[i] = make([]float64, cols+1)
+		copy(tb.t[i], r.Coef)
+		tb.t[i][cols] = r.RHS
+		switch r.Op {
+		case LE:
+			tb.t[i][s] = 1
+			tb.basis[i] = s
+			s++
+		case GE:
+			tb.t[i][s] = -1
+			s++
+			fallthrough
+		case EQ:
+			tb.t[i][a] = 1
+			tb.basis[i] = a
+			a++
+		}
+	}
+	artificial := func(j int) bool { return j >= n+slacks }
+	if artificials > 0 {
+		phase1 := make([]float64, cols)
+		for j := n + slacks; j < cols; j++ {
+			phase1[j] = 1
+		}
+		if err := tb.minimize(phase1, func(int) bool { return true }); err != nil {
+			return nil, err
+		}
+		sum := 0.0
+		for i, b := range tb.basis {
+			if artificial(b) {
+				sum += tb.t[i][cols]
+			}
+		}
+		if sum > 1e-7 {
+			return nil, ErrInfeasible
+		}
+		// pivot artificial variables left in the basis at 0 out of it where possible
+		for i, b := range tb.basis {
+			if !artificial(b) {
+				continue
+			}
+			for j := 0; j < n+slacks; j++ {
+				if math.Abs(tb.t[i][j]) > eps {
+					tb.pivot(i, j)
+					break
+				}
+			}
+		}
+	}
+	phase2 := make([]float64, cols)
+	copy(phase2, c)
+	if err := tb.minimize(phase2, func(j int) bool { return !artificial(j) }); err != nil {
+		return nil, err
+	}
+	x := make([]float64, n)
+	for i, b := range tb.basis {
+		if b < n {
+			x[b] = tb.t[i][cols]
+		}
+	}
+	return x, nil
+}
+
+// minimize pivots until no column allowed to enter the basis has a negative reduced cost
+func (tb *tableau) minimize(c []float64, allowed func(int) bool) error {
+	if len(tb.t) == 0 {
+		return nil
+	}
+	cols := len(tb.t[0]) - 1
+	for it := 0; it < maxIterations; it++ {
+		if !tb.deadline.IsZero() && time.Now().After(tb.deadline) {
+			return ErrDeadline
+		}
+		enter := -1
+		for j := 0; j < cols && enter < 0; j++ {
+			if !allowed(j) {
+				continue
+			}
+			r := c[j]
+			for i, b := range tb.basis {
+				r -= c[b] * tb.t[i][j]
+			}
+			if r < -eps {
+				enter = j
+			}
+		}
+		if enter < 0 {
+			return nil
+		}
+		leave := -1
+		ratio := math.Inf(1)
+		for i := range tb.t {
+			if tb.t[i][enter] <= eps {
+				continue
+			}
+			q := tb.t[i][cols] / tb.t[i][enter]
+			if q < ratio-eps || (q <= ratio+eps && leave >= 0 && tb.basis[i] < tb.basis[leave]) {
+				ratio, leave = q, i
+			}
+		}
+		if leave < 0 {
+			return ErrUnbounded
+		}
+		tb.pivot(leave, enter)
+	}
+	return ErrIterations
+}
+
+// pivot makes a column basic in a row
+func (tb *tableau) pivot(row int, col int) {
+	pr := tb.t[row]
+	pv := pr[col]
+	for j := range pr {
+		pr[j] /= pv
+	}
+	for i, r := range tb.t {
+		if i == row || r[col] == 0 {
+			continue
+		}
+		f := r[col]
+		for j := range r {
+			r[j] -= f * pr[j]
+		}
+	}
+	tb.basis[row] = col
+}
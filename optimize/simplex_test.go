@@ -0,0 +1,58 @@
+package optimize
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSolve(t *testing.T) {
+	inf := math.Inf(1)
+	// minimize 2x + 3y with x + y >= 4, x + 3y >= 6 and x <= 3
+	p := Problem{
+		Objective:   []float64{2, 3},
+		Constraints: []Constraint{{[]float64{1, 1}, GE, 4}, {[]float64{1, 3}, GE, 6}},
+		Lower:       []float64{0, 0},
+		Upper:       []float64{3, inf},
+	}
+	x, obj, err := Solve(p)
+	if err != nil || math.Abs(x[0]-3) > 1e-6 || math.Abs(x[1]-1) > 1e-6 || math.Abs(obj-9) > 1e-6 {
+		t.Errorf("solution is %v %f %v SB [3 1] 9", x, obj, err)
+	}
+	// maximize x + y as minimize -x - y with 2x + 2y <= 5 in whole numbers
+	p = Problem{
+		Objective:   []float64{-1, -1},
+		Constraints: []Constraint{{[]float64{2, 2}, LE, 5}, {[]float64{1, -1}, EQ, 0}},
+		Lower:       []float64{0, 0},
+		Upper:       []float64{inf, inf},
+		Integer:     true,
+	}
+	if x, obj, err = Solve(p); err != nil || x[0] != 1 || x[1] != 1 || obj != -2 {
+		t.Errorf("integer solution is %v %f %v SB [1 1] -2", x, obj, err)
+	}
+	// the first integer solution is found on the second node
+	defer func(n int) { maxNodes = n }(maxNodes)
+	maxNodes = 2
+	if x, obj, err = Solve(p); err != ErrNodes || x == nil || obj != -2 {
+		t.Errorf("node limited solution is %v %f %v SB [1 1] -2 %v", x, obj, err, ErrNodes)
+	}
+	maxNodes = 1
+	if x, _, err = Solve(p); err != ErrNodes || x != nil {
+		t.Errorf("Expecting %v without a solution, got %v %v", ErrNodes, x, err)
+	}
+	maxNodes = 20000
+	p.Deadline = time.Now().Add(-time.Second)
+	if x, _, err = Solve(p); err != ErrDeadline || x != nil {
+		t.Errorf("Expecting %v without a solution, got %v %v", ErrDeadline, x, err)
+	}
+	p.Deadline = time.Time{}
+	p = Problem{
+		Objective:   []float64{1},
+		Constraints: []Constraint{{[]float64{1}, GE, 5}},
+		Lower:       []float64{0},
+		Upper:       []float64{4},
+	}
+	if _, _, err = Solve(p); err != ErrInfeasible {
+		t.Errorf("Expecting %v, got %v", ErrInfeasible, err)
+	}
+}
@@ -0,0 +1,144 @@
+// Package provenance classifies how nutrient values were derived from their derivation codes and
+// rates the confidence in a value from it's derivation and number of samples
+package provenance
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+// ANALYTICAL etc are the kinds of derivations
+const (
+	ANALYTICAL = "analytical"
+	LABEL      = "label"
+	CALCULATED = "calculated"
+	IMPUTED    = "imputed"
+	ASSUMED    = "assumed"
+	UNKNOWN    = "unknown"
+)
+
+// HIGH etc are the confidence tiers
+const (
+	HIGH   = "high"
+	MEDIUM = "medium"
+	LOW    = "low"
+)
+
+// minSamples is the number of analyzed samples needed for a high confidence value
+const minSamples = 3
+
+// codes maps the SR Legacy, FNDDS and Branded Foods derivation codes to their kind
+var codes = map[string]string{
+	"A": ANALYTICAL, "AR": ANALYTICAL, "AS": ANALYTICAL,
+	"MA": LABEL, "LC": LABEL, "LCCD": LABEL, "LCCS": LABEL, "LCSA": LABEL, "LCSG": LABEL, "LCSL": LABEL,
+	"BFCN": LABEL, "BFFN": LABEL, "BFNN": LABEL, "BFPN": LABEL, "BFSN": LABEL, "BFYN": LABEL,
+	"NC": CALCULATED, "NR": CALCULATED, "RA": CALCULATED, "RC": CALCULATED, "RF": CALCULATED, "RK": CALCULATED,
+	"RKA": CALCULATED, "RP": CALCULATED, "RPA": CALCULATED, "RPI": CALCULATED, "RPT": CALCULATED, "S": CALCULATED,
+	"AI": IMPUTED, "BD": IMPUTED, "BU": IMPUTED, "BNA": IMPUTED, "I": IMPUTED,
+	"Z": ASSUMED, "BFZN": ASSUMED, "CAZN": ASSUMED, "PAZN": ASSUMED,
+}
+
+// terms classifies derivations with codes which aren't in codes by their description.  Terms
+// are tried in order.
+var terms = []struct {
+	term, kind string
+}{
+	{"assumed", ASSUMED},
+	{"imputed", IMPUTED},
+	{"based on", IMPUTED},
+	{"another form", IMPUTED},
+	{"similar food", IMPUTED},
+	{"label", LABEL},
+	{"manufacturer", LABEL},
+	{"calculated", CALCULATED},
+	{"recipe", CALCULATED},
+	{"summed", CALCULATED},
+	{"retention", CALCULATED},
+	{"analytical", ANALYTICAL},
+	{"analysis", ANALYTICAL},
+}
+
+// Kinds returns the kinds of derivations values can be filtered on
+func Kinds() []string {
+	return []string{ANALYTICAL, LABEL, CALCULATED, IMPUTED, ASSUMED}
+}
+
+// Kind returns the kind of a derivation from it's code or, for codes which aren't recognized,
+// it's description
+func Kind(d *fdc.Derivation) string {
+	if d == nil {
+		return UNKNOWN
+	}
+	if k, ok := codes[strings.ToUpper(strings.TrimSpace(d.Code))]; ok {
+		return k
+	}
+	desc := strings.ToLower(d.Description)
+	for _, t := range terms {
+		if strings.Contains(desc, t.term) {
+			return t.kind
+		}
+	}
+	return UNKNOWN
+}
+
+// Confidence rates a value: analytical values from at least 3 samples are high, other
+// analytical and label values are medium and calculated, imputed and assumed values are low
+func Confidence(kind string, datapoints int) string {
+	switch kind {
+	case ANALYTICAL:
+		if datapoints >= minSamples {
+			return HIGH
+		}
+		return MEDIUM
+	case LABEL:
+		return MEDIUM
+	case CALCULATED, IMPUTED, ASSUMED:
+		return LOW
+	}
+	return UNKNOWN
+}
+
+// Of returns the provenance of a nutrient value.  The min and max are omitted when both are 0.
+func Of(nd fdc.NutrientData) *fdc.Provenance {
+	k := Kind(nd.Derivation)
+	p := fdc.Provenance{Derivation: nd.Derivation, Kind: k, Datapoints: nd.Datapoints, Confidence: Confidence(k, nd.Datapoints)}
+	if nd.Min != 0 || nd.Max != 0 {
+		min, max := float64(nd.Min), float64(nd.Max)
+		p.Min, p.Max = &min, &max
+	}
+	return &p
+}
+
+// Filter returns the filter which leaves the kinds of derivations out of a nutrient report
+func Filter(kinds []string) (*fdc.DerivationFilter, error) {
+	var f fdc.DerivationFilter
+	exclude := map[string]bool{}
+	for _, k := range kinds {
+		valid := false
+		for _, v := range Kinds() {
+			valid = valid || k == v
+		}
+		if !valid {
+			return nil, fmt.Errorf("%s is not one of the derivations %s", k, strings.Join(Kinds(), ", "))
+		}
+		exclude[k] = true
+	}
+	for c, k := range codes {
+		f.Known = append(f.Known, c)
+		if exclude[k] {
+			f.Codes = append(f.Codes, c)
+		}
+	}
+	for _, t := range terms {
+		f.Precedence = append(f.Precedence, t.term)
+		if exclude[t.kind] {
+			f.Terms = append(f.Terms, t.term)
+		}
+	}
+	sort.Strings(f.Codes)
+	sort.Strings(f.Known)
+	return &f, nil
+}
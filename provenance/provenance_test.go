@@ -0,0 +1,71 @@
+package provenance
+
+import (
+	"testing"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+func TestKind(t *testing.T) {
+	tests := []struct {
+		d    *fdc.Derivation
+		kind string
+	}{
+		{&fdc.Derivation{Code: "A", Description: "Analytical data"}, ANALYTICAL},
+		{&fdc.Derivation{Code: "lccs", Description: "Calculated from value per serving size measure"}, LABEL},
+		{&fdc.Derivation{Code: "BFZN", Description: "Assumed zero"}, ASSUMED},
+		{&fdc.Derivation{Code: "XX", Description: "Imputed from a similar food"}, IMPUTED},
+		{&fdc.Derivation{Code: "XY", Description: "Calculated from a recipe"}, CALCULATED},
+		{&fdc.Derivation{Code: "XZ"}, UNKNOWN},
+		{nil, UNKNOWN},
+	}
+	for _, tt := range tests {
+		if k := Kind(tt.d); k != tt.kind {
+			t.Errorf("%+v is %s SB %s", tt.d, k, tt.kind)
+		}
+	}
+}
+
+func TestOf(t *testing.T) {
+	p := Of(fdc.NutrientData{Derivation: &fdc.Derivation{Code: "A"}, Datapoints: 12, Min: 1.5, Max: 3})
+	if p.Confidence != HIGH || p.Min == nil || *p.Max != 3 {
+		t.Errorf("12 analyzed samples are %+v SB high with a min and max", p)
+	}
+	if p := Of(fdc.NutrientData{Derivation: &fdc.Derivation{Code: "A"}, Datapoints: 1}); p.Confidence != MEDIUM || p.Min != nil {
+		t.Errorf("1 analyzed sample is %+v SB medium without a min and max", p)
+	}
+	if p := Of(fdc.NutrientData{Derivation: &fdc.Derivation{Code: "NC"}, Datapoints: 5}); p.Confidence != LOW {
+		t.Errorf("a calculated value is %s SB %s", p.Confidence, LOW)
+	}
+	if p := Of(fdc.NutrientData{}); p.Kind != UNKNOWN || p.Confidence != UNKNOWN {
+		t.Errorf("a value without a derivation is %+v SB unknown", p)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	f, err := Filter([]string{IMPUTED, CALCULATED})
+	if err != nil {
+		t.Fatal(err)
+	}
+	has := func(l []string, s string) bool {
+		for _, v := range l {
+			if v == s {
+				return true
+			}
+		}
+		return false
+	}
+	if !has(f.Codes, "BD") || !has(f.Codes, "NC") || has(f.Codes, "A") || !has(f.Known, "A") {
+		t.Errorf("filter codes are %v", f.Codes)
+	}
+	if !has(f.Terms, "imputed") || has(f.Terms, "label") {
+		t.Errorf("filter terms are %v", f.Terms)
+	}
+	// descriptions are classified on their first term so every term is listed in order
+	if len(f.Precedence) != len(terms) || f.Precedence[0] != terms[0].term {
+		t.Errorf("filter precedence is %v", f.Precedence)
+	}
+	if _, err := Filter([]string{"guessed"}); err == nil {
+		t.Errorf("Expecting an error filtering on an unknown derivation")
+	}
+}
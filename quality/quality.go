@@ -0,0 +1,90 @@
+// Package quality checks foods for nutrient values which are inconsistent with each other or
+// impossible
+package quality
+
+import (
+	"fmt"
+	"math"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+// ENERGY etc are the names of the data quality rules
+const (
+	ENERGY        = "energy"
+	SUGARS        = "sugarsExceedCarbohydrate"
+	SATURATEDFAT  = "saturatedFatExceedsFat"
+	NEGATIVE      = "negativeValue"
+	SERVINGWEIGHT = "servingWeight"
+)
+
+// DefaultTolerance is the percent reported energy may differ from calculated energy
+const DefaultTolerance = 20.0
+
+// minEnergyDeviation is the kcal per 100 g reported energy may always differ from calculated
+// energy so low energy foods aren't flagged for rounding
+const minEnergyDeviation = 10.0
+
+// fiberFactor is the kcal per gram of fiber, which is included in carbohydrate by difference
+const fiberFactor = 2.0
+
+// Rules returns the names of the data quality rules
+func Rules() []string {
+	return []string{ENERGY, SUGARS, SATURATEDFAT, NEGATIVE, SERVINGWEIGHT}
+}
+
+// Energy calculates kcal per 100 g from protein, fat, carbohydrate, fiber and alcohol using the
+// general Atwater factors.  Fiber is counted at 2 kcal per gram in place of the carbohydrate
+// factor.  The food must have values for protein, fat and carbohydrate.
+func Energy(values fdc.NutrientValues) (float64, bool) {
+	kcal := 0.0
+	for _, n := range []int{fdc.PROTEIN, fdc.TOTALFAT, fdc.CARBOHYDRATE} {
+		v, ok := values[n]
+		if !ok {
+			return 0, false
+		}
+		kcal += v * fdc.AtwaterFactors[n]
+	}
+	kcal += values[fdc.ALCOHOL]*fdc.AtwaterFactors[fdc.ALCOHOL] - values[fdc.FIBER]*(fdc.AtwaterFactors[fdc.CARBOHYDRATE]-fiberFactor)
+	return math.Round(kcal*10) / 10, true
+}
+
+// Check applies the data quality rules to a food and it's NUTDATA.  Reported energy is flagged
+// when it differs from calculated energy by more than the tolerance percent of calculated energy.
+func Check(f fdc.Food, nd []fdc.NutrientData, tolerance float64) fdc.QualityReport {
+	var dt fdc.DocType
+	r := fdc.QualityReport{FdcID: f.FdcID, Description: f.Description, Source: f.Source, Type: dt.ToString(fdc.QUALITY), Tolerance: tolerance, Findings: []fdc.QualityFinding{}}
+	values := fdc.NewNutrientValues(nd)
+	if kcal, ok := Energy(values); ok {
+		r.Energy = &kcal
+		if reported, ok := values[fdc.ENERGY]; ok && math.Abs(reported-kcal) > math.Max(kcal*tolerance/100, minEnergyDeviation) {
+			r.Findings = append(r.Findings, finding(ENERGY, fdc.ENERGY, fmt.Sprintf("Reported energy differs from %.1f kcal calculated from protein, fat, carbohydrate, fiber and alcohol by more than %g%%", kcal, tolerance), reported, kcal))
+		}
+	}
+	// a part is only compared to it's whole when the food reports both
+	if sugars, ok := values[fdc.SUGARS]; ok {
+		if carbs, ok := values[fdc.CARBOHYDRATE]; ok && sugars > carbs {
+			r.Findings = append(r.Findings, finding(SUGARS, fdc.SUGARS, "Total sugars exceed carbohydrate", sugars, carbs))
+		}
+	}
+	if satfat, ok := values[fdc.SATURATEDFAT]; ok {
+		if fat, ok := values[fdc.TOTALFAT]; ok && satfat > fat {
+			r.Findings = append(r.Findings, finding(SATURATEDFAT, fdc.SATURATEDFAT, "Saturated fat exceeds total fat", satfat, fat))
+		}
+	}
+	for _, n := range nd {
+		if v := n.Value; v < 0 {
+			r.Findings = append(r.Findings, fdc.QualityFinding{Rule: NEGATIVE, Nutrientno: n.Nutrientno, Message: fmt.Sprintf("%s is negative", n.Nutrient), Reported: &v})
+		}
+	}
+	for _, s := range f.Servings {
+		if s.Weight <= 0 {
+			r.Findings = append(r.Findings, fdc.QualityFinding{Rule: SERVINGWEIGHT, Message: fmt.Sprintf("Serving %s has no weight", s.Description)})
+		}
+	}
+	return r
+}
+
+func finding(rule string, nutrientno int, message string, reported float64, expected float64) fdc.QualityFinding {
+	return fdc.QualityFinding{Rule: rule, Nutrientno: nutrientno, Message: message, Reported: &reported, Expected: &expected}
+}
@@ -0,0 +1,56 @@
+package quality
+
+import (
+	"testing"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+func rules(r fdc.QualityReport) map[string]bool {
+	m := map[string]bool{}
+	for _, f := range r.Findings {
+		m[f.Rule] = true
+	}
+	return m
+}
+
+func TestEnergy(t *testing.T) {
+	if kcal, ok := Energy(fdc.NutrientValues{fdc.PROTEIN: 5, fdc.TOTALFAT: 20, fdc.CARBOHYDRATE: 70, fdc.FIBER: 3}); !ok || kcal != 474 {
+		t.Errorf("energy is %.1f SB 474", kcal)
+	}
+	if _, ok := Energy(fdc.NutrientValues{fdc.PROTEIN: 5, fdc.TOTALFAT: 20}); ok {
+		t.Errorf("Expecting no energy without carbohydrate")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	nd := []fdc.NutrientData{
+		{Nutrientno: fdc.ENERGY, Value: 470}, {Nutrientno: fdc.PROTEIN, Value: 5}, {Nutrientno: fdc.TOTALFAT, Value: 20},
+		{Nutrientno: fdc.CARBOHYDRATE, Value: 70}, {Nutrientno: fdc.FIBER, Value: 3}, {Nutrientno: fdc.SUGARS, Value: 40},
+		{Nutrientno: fdc.SATURATEDFAT, Value: 6},
+	}
+	f := fdc.Food{FdcID: "1", Servings: []fdc.Serving{{Description: "cookie", Weight: 34}}}
+	if r := Check(f, nd, DefaultTolerance); len(r.Findings) != 0 {
+		t.Errorf("Expecting no findings, got %v", r.Findings)
+	}
+	nd[0].Value = 250
+	nd[5].Value = 75
+	nd[6].Value = 21
+	nd = append(nd, fdc.NutrientData{Nutrientno: fdc.SODIUM, Nutrient: "Sodium, Na", Value: -1})
+	f.Servings = append(f.Servings, fdc.Serving{Description: "package"})
+	got := rules(Check(f, nd, DefaultTolerance))
+	for _, rule := range Rules() {
+		if !got[rule] {
+			t.Errorf("Expecting a %s finding", rule)
+		}
+	}
+	// a 60% tolerance allows 250 kcal reported for 474 calculated
+	if got := rules(Check(f, nd, 60)); got[ENERGY] {
+		t.Errorf("Expecting no %s finding with a 60%% tolerance", ENERGY)
+	}
+	// sugars and saturated fat without carbohydrate and total fat aren't compared to 0
+	partial := []fdc.NutrientData{{Nutrientno: fdc.SUGARS, Value: 10}, {Nutrientno: fdc.SATURATEDFAT, Value: 2}}
+	if got := rules(Check(fdc.Food{FdcID: "2"}, partial, DefaultTolerance)); got[SUGARS] || got[SATURATEDFAT] {
+		t.Errorf("Expecting no %s or %s finding without carbohydrate and total fat", SUGARS, SATURATEDFAT)
+	}
+}
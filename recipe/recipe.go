@@ -0,0 +1,127 @@
+// Package recipe calculates the nutrient content of recipes from the nutrient data of their ingredients
+package recipe
+
+import (
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"sort"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+	"github.com/littlebunch/fdc-api/units"
+)
+
+// Food is an ingredient's food and it's NUTDATA
+type Food struct {
+	Food      fdc.Food
+	Nutrients []fdc.NutrientData
+}
+
+// Checksum fingerprints the NUTDATA values of a recipe's foods so a cached analysis can be found
+// to be stale when any of them change.  The order of the values doesn't matter.
+func Checksum(nd []fdc.NutrientData) string {
+	var v []string
+	for _, d := range nd {
+		v = append(v, fmt.Sprintf("%s_%d=%g", d.FdcID, d.Nutrientno, d.Value))
+	}
+	sort.Strings(v)
+	h := sha1.New()
+	for _, s := range v {
+		fmt.Fprintln(h, s)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// Analyze sums the nutrients of a recipe's ingredients after applying retention factors and returns
+// the totals per recipe, per serving and per 100 grams of the cooked recipe.  Foods are keyed by the
+// fdcId of each ingredient.
+func Analyze(r fdc.RecipeRequest, foods map[string]Food) (fdc.RecipeAnalysis, error) {
+	var ra fdc.RecipeAnalysis
+	if len(r.Ingredients) == 0 {
+		return ra, errors.New("a recipe requires at least one ingredient")
+	}
+	if r.Servings < 0 || r.YieldWeight < 0 || r.YieldFactor < 0 {
+		return ra, errors.New("servings, yieldWeight and yieldFactor must be greater than or equal to 0")
+	}
+	if err := validRetention(r.Retention); err != nil {
+		return ra, err
+	}
+	for _, i := range r.Ingredients {
+		if err := validRetention(i.Retention); err != nil {
+			return ra, fmt.Errorf("%s: %v", i.FdcID, err)
+		}
+	}
+	total := fdc.NutrientValues{}
+	meta := map[int]fdc.NutrientData{}
+	for _, i := range r.Ingredients {
+		f, ok := foods[i.FdcID]
+		if !ok {
+			return ra, fmt.Errorf("no food found for ingredient %s", i.FdcID)
+		}
+		g, err := units.Grams(i.Amount, i.Unit, f.Food.Servings)
+		if err != nil {
+			return ra, fmt.Errorf("%s: %v", i.FdcID, err)
+		}
+		ia := fdc.IngredientAnalysis{FdcID: i.FdcID, Description: f.Food.Description, Amount: i.Amount, Unit: i.Unit, Weight: g}
+		values := fdc.NewNutrientValues(f.Nutrients).Scale(g)
+		for _, n := range f.Nutrients {
+			meta[n.Nutrientno] = n
+		}
+		for n, v := range values {
+			if rf, ok := i.Retention[n]; ok {
+				v = v * rf / 100
+			} else if rf, ok := r.Retention[n]; ok {
+				v = v * rf / 100
+			}
+			values[n] = v
+		}
+		total.Add(values)
+		ia.Nutrients = nutrients(values, meta)
+		ra.Ingredients = append(ra.Ingredients, ia)
+		ra.RawWeight += g
+	}
+	ra.CookedWeight = ra.RawWeight
+	if r.YieldWeight > 0 {
+		ra.CookedWeight = r.YieldWeight
+	} else if r.YieldFactor > 0 {
+		ra.CookedWeight = ra.RawWeight * r.YieldFactor
+	}
+	ra.Servings = r.Servings
+	if ra.Servings == 0 {
+		ra.Servings = 1
+	}
+	ra.ServingWeight = ra.CookedWeight / ra.Servings
+	ra.Total = nutrients(total, meta)
+	// Scale takes a weight per 100 units so these divide the total by the servings and
+	// by the number of 100 grams in the cooked weight
+	ra.PerServing = nutrients(total.Scale(100/ra.Servings), meta)
+	if ra.CookedWeight > 0 {
+		ra.Per100g = nutrients(total.Scale(100*100/ra.CookedWeight), meta)
+	}
+	return ra, nil
+}
+
+// validRetention returns an error if a retention factor isn't a percentage from 0 to 100
+func validRetention(rf map[int]float64) error {
+	for n, v := range rf {
+		if !(v >= 0 && v <= 100) {
+			return fmt.Errorf("retention factor %g for nutrient %d must be from 0 to 100", v, n)
+		}
+	}
+	return nil
+}
+
+// nutrients lists values in nutrient number order with their names and units
+func nutrients(values fdc.NutrientValues, meta map[int]fdc.NutrientData) []fdc.RecipeNutrient {
+	var rn []fdc.RecipeNutrient
+	for n, v := range values {
+		m, ok := meta[n]
+		if !ok && n == fdc.VITAMIND {
+			// converted from vitamin D in IU
+			m = fdc.NutrientData{Nutrient: "Vitamin D (D2 + D3)", Unit: "µg"}
+		}
+		rn = append(rn, fdc.RecipeNutrient{Nutrientno: n, Nutrient: m.Nutrient, Unit: m.Unit, Value: v})
+	}
+	sort.Slice(rn, func(i, j int) bool { return rn[i].Nutrientno < rn[j].Nutrientno })
+	return rn
+}
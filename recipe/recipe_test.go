@@ -0,0 +1,67 @@
+package recipe
+
+import (
+	"math"
+	"testing"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+func TestAnalyze(t *testing.T) {
+	foods := map[string]Food{
+		"1": {Food: fdc.Food{FdcID: "1", Description: "Rice, raw", Servings: []fdc.Serving{{Description: "cup", Servingamount: 1, Weight: 185}}},
+			Nutrients: []fdc.NutrientData{{Nutrientno: 208, Nutrient: "Energy", Unit: "kcal", Value: 365}, {Nutrientno: 401, Nutrient: "Vitamin C", Unit: "mg", Value: 10}}},
+		"2": {Food: fdc.Food{FdcID: "2", Description: "Butter"},
+			Nutrients: []fdc.NutrientData{{Nutrientno: 208, Nutrient: "Energy", Unit: "kcal", Value: 717}}},
+	}
+	r := fdc.RecipeRequest{
+		Ingredients: []fdc.Ingredient{{FdcID: "1", Amount: 1, Unit: "cup"}, {FdcID: "2", Amount: 15, Unit: "g"}},
+		Servings:    4,
+		YieldFactor: 2.5,
+		Retention:   map[int]float64{401: 50},
+	}
+	ra, err := Analyze(r, foods)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ra.RawWeight != 200 || ra.CookedWeight != 500 || ra.ServingWeight != 125 {
+		t.Errorf("weights are %f %f %f SB 200 500 125", ra.RawWeight, ra.CookedWeight, ra.ServingWeight)
+	}
+	energy := 365*1.85 + 717*0.15
+	if e := ra.Total[0]; e.Nutrientno != 208 || math.Abs(e.Value-energy) > 0.001 {
+		t.Errorf("total energy is %v SB %f", e, energy)
+	}
+	if e := ra.PerServing[0]; math.Abs(e.Value-energy/4) > 0.001 {
+		t.Errorf("energy per serving is %f SB %f", e.Value, energy/4)
+	}
+	if e := ra.Per100g[0]; math.Abs(e.Value-energy/5) > 0.001 {
+		t.Errorf("energy per 100g is %f SB %f", e.Value, energy/5)
+	}
+	if c := ra.Total[1]; c.Nutrientno != 401 || math.Abs(c.Value-9.25) > 0.001 {
+		t.Errorf("vitamin C is %v SB 9.25", c)
+	}
+	if _, err = Analyze(fdc.RecipeRequest{Ingredients: []fdc.Ingredient{{FdcID: "2", Amount: 1, Unit: "cup"}}}, foods); err == nil {
+		t.Errorf("Expecting an error converting a cup of a food without volume servings")
+	}
+	r.Retention = map[int]float64{401: 150}
+	if _, err = Analyze(r, foods); err == nil {
+		t.Errorf("Expecting an error for a retention factor over 100")
+	}
+	r.Retention = nil
+	r.Ingredients[0].Retention = map[int]float64{208: -10}
+	if _, err = Analyze(r, foods); err == nil {
+		t.Errorf("Expecting an error for a negative ingredient retention factor")
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	nd := []fdc.NutrientData{{FdcID: "1", Nutrientno: 208, Value: 365}, {FdcID: "2", Nutrientno: 208, Value: 717}}
+	c := Checksum(nd)
+	if Checksum([]fdc.NutrientData{nd[1], nd[0]}) != c {
+		t.Errorf("Checksum depends on the order of the values")
+	}
+	nd[1].Value = 716
+	if Checksum(nd) == c {
+		t.Errorf("Checksum unchanged after a value changed")
+	}
+}
@@ -0,0 +1,175 @@
+// Package score rates foods with nutrient profiling models: the Nutri-Score, the Health Star
+// Rating and the Nutrient Rich Foods index
+package score
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/littlebunch/fdc-api/dri"
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+// points returns the number of thresholds a value exceeds
+func points(v float64, thresholds []float64) int {
+	p := 0
+	for _, t := range thresholds {
+		if v > t {
+			p++
+		}
+	}
+	return p
+}
+
+var (
+	// fruit, vegetable and legume food groups counted as 100% fruit, vegetables and legumes
+	fvlGroup = regexp.MustCompile(`fruit|vegetable|legume`)
+	fvlTerms = regexp.MustCompile(`(^|[^a-z])(apples?|apricots?|bananas?|beans?|beets?|berries|blueberries|broccoli|cabbage|carrots?|cherries|chickpeas?|corn|cranberries|cucumbers?|grapes?|kale|lentils?|lettuce|mangos?|oranges?|peas|peaches|pears?|peppers?|pineapples?|potatoes|pumpkin|raisins?|raspberries|spinach|squash|strawberries|tomato(es)?|vegetables?|fruits?)([^a-z]|$)`)
+)
+
+// FruitVegetables estimates the percent of a food which is fruits, vegetables and legumes.  Foods in
+// a fruit, vegetable or legume food group are 100%.  Otherwise the declared percents of the food's
+// ingredients naming a fruit, vegetable or legume are summed.
+func FruitVegetables(f fdc.Food, items []fdc.IngredientItem) float64 {
+	if f.Group != nil && fvlGroup.MatchString(strings.ToLower(f.Group.Description)) {
+		return 100
+	}
+	pct := 0.0
+	for _, i := range items {
+		if i.Percent != nil && fvlTerms.MatchString(i.Name) {
+			pct += *i.Percent
+		}
+	}
+	return math.Min(pct, 100)
+}
+
+// Scores rates a food from it's nutrient values per 100 g and the estimated percent of fruits,
+// vegetables and legumes.  Scores which need a nutrient the food lacks are omitted.
+func Scores(values fdc.NutrientValues, fvl float64) *fdc.NutrientScores {
+	s := fdc.NutrientScores{NutriScore: NutriScore(values, fvl), HealthStar: HealthStar(values, fvl)}
+	if nrf, ok := NRF(values); ok {
+		s.NRF = &nrf
+	}
+	return &s
+}
+
+// requires returns true if the values include each nutrient
+func requires(values fdc.NutrientValues, nutrients ...int) bool {
+	for _, n := range nutrients {
+		if _, ok := values[n]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// NutriScore computes the 2023 Nutri-Score for general foods.  Beverages, cheese and fats, oils,
+// nuts and seeds have their own tables which aren't applied.
+func NutriScore(values fdc.NutrientValues, fvl float64) *fdc.NutriScore {
+	if !requires(values, fdc.ENERGY, fdc.SUGARS, fdc.SATURATEDFAT, fdc.SODIUM) {
+		return nil
+	}
+	kj := values[fdc.ENERGY] * 4.184
+	salt := values[fdc.SODIUM] * 2.5 / 1000
+	n := points(kj, []float64{335, 670, 1005, 1340, 1675, 2010, 2345, 2680, 3015, 3350}) +
+		points(values[fdc.SUGARS], []float64{3.4, 6.8, 10, 14, 17, 20, 24, 27, 31, 34, 37, 41, 44, 48, 51}) +
+		points(values[fdc.SATURATEDFAT], []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}) +
+		points(salt, []float64{0.2, 0.4, 0.6, 0.8, 1, 1.2, 1.4, 1.6, 1.8, 2, 2.2, 2.4, 2.6, 2.8, 3, 3.2, 3.4, 3.6, 3.8, 4})
+	protein := points(values[fdc.PROTEIN], []float64{2.4, 4.8, 7.2, 9.6, 12, 14, 17})
+	fiber := points(values[fdc.FIBER], []float64{3, 4.1, 5.2, 6.3, 7.4})
+	fv := 0
+	switch {
+	case fvl > 80:
+		fv = 5
+	case fvl > 60:
+		fv = 2
+	case fvl > 40:
+		fv = 1
+	}
+	p := fiber + fv
+	// protein isn't counted for less healthy foods unless they're mostly fruit and vegetables
+	if n < 11 || fv == 5 {
+		p += protein
+	}
+	ns := fdc.NutriScore{Score: n - p, Negative: n, Positive: p, FruitVegetables: fvl}
+	switch {
+	case ns.Score <= 0:
+		ns.Grade = "A"
+	case ns.Score <= 2:
+		ns.Grade = "B"
+	case ns.Score <= 10:
+		ns.Grade = "C"
+	case ns.Score <= 18:
+		ns.Grade = "D"
+	default:
+		ns.Grade = "E"
+	}
+	return &ns
+}
+
+// HealthStar computes the Health Star Rating using the 2020 revision's tables for category 2 foods.
+// Beverages, dairy and fats and oils have their own tables which aren't applied.
+func HealthStar(values fdc.NutrientValues, fvl float64) *fdc.HealthStar {
+	if !requires(values, fdc.ENERGY, fdc.SUGARS, fdc.SATURATEDFAT, fdc.SODIUM) {
+		return nil
+	}
+	kj := values[fdc.ENERGY] * 4.184
+	var energy, satfat, sugars, sodium []float64
+	for i := 1; i <= 30; i++ {
+		energy = append(energy, float64(i)*335)
+		sodium = append(sodium, float64(i)*90)
+	}
+	satfat = []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11.2, 12.5, 13.9, 15.5, 17.3, 19.3, 21.6, 24.1, 26.9, 30,
+		33.5, 37.4, 41.7, 46.6, 52, 58.1, 64.8, 72.4, 80.8, 90.2}
+	sugars = []float64{5, 8.9, 12.8, 16.8, 20.7, 24.6, 28.5, 32.4, 36.3, 40.3, 44.2, 48.1, 52, 55.9, 59.8, 63.8,
+		67.7, 71.6, 75.5, 79.4, 83.3, 87.3, 91.2, 95.1, 99}
+	baseline := points(kj, energy) + points(values[fdc.SATURATEDFAT], satfat) + points(values[fdc.SUGARS], sugars) + points(values[fdc.SODIUM], sodium)
+	v := 0
+	for _, t := range []float64{25, 43, 52, 63, 67, 80, 90, 100} {
+		if fvl >= t {
+			v++
+		}
+	}
+	protein := points(values[fdc.PROTEIN], []float64{1.6, 3.2, 4.8, 6.4, 8, 9.6, 11.6, 13.9, 16.7, 20, 24, 28.9, 34.7, 41.6, 50})
+	fiber := points(values[fdc.FIBER], []float64{0.9, 1.9, 2.8, 3.7, 4.7, 5.4, 6.3, 7.3, 8.4, 9.7, 11.2, 13, 15, 17.3, 20})
+	s := baseline - v - fiber
+	// protein is only counted for foods with fewer than 13 baseline points or at least 5 V points
+	if baseline < 13 || v >= 5 {
+		s -= protein
+	}
+	stars := 0.5
+	for i, t := range []int{24, 20, 15, 11, 6, 2, -2, -7, -11} {
+		if s <= t {
+			stars = 1 + float64(i)*0.5
+		}
+	}
+	return &fdc.HealthStar{Score: s, Stars: stars}
+}
+
+// nrf are the nutrients to encourage in the NRF9.3 index
+var nrf = []int{fdc.PROTEIN, fdc.FIBER, 320, 401, 323, 301, 303, 306, 304}
+
+// NRF computes the Nutrient Rich Foods index NRF9.3 per 100 kcal: the sum of the percent Daily
+// Values of nine nutrients to encourage, each capped at 100, less the sum of the percent Daily
+// Values of saturated fat, added sugars and sodium.  Total sugars are used when a food has no
+// value for added sugars.
+func NRF(values fdc.NutrientValues) (float64, bool) {
+	kcal := values[fdc.ENERGY]
+	if kcal <= 0 {
+		return 0, false
+	}
+	per100 := func(n int) float64 {
+		return values[n] * 100 / kcal / dri.DailyValues[n].Value * 100
+	}
+	sum := 0.0
+	for _, n := range nrf {
+		sum += math.Min(per100(n), 100)
+	}
+	sugars := fdc.ADDEDSUGARS
+	if _, ok := values[sugars]; !ok {
+		sugars = fdc.SUGARS
+	}
+	limits := per100(fdc.SATURATEDFAT) + per100(fdc.SODIUM) + values[sugars]*100/kcal/dri.DailyValues[fdc.ADDEDSUGARS].Value*100
+	return math.Round((sum-limits)*10) / 10, true
+}
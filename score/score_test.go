@@ -0,0 +1,53 @@
+package score
+
+import (
+	"math"
+	"testing"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+var (
+	cookie = fdc.NutrientValues{fdc.ENERGY: 470, fdc.PROTEIN: 5, fdc.TOTALFAT: 20, fdc.CARBOHYDRATE: 70, fdc.FIBER: 3,
+		fdc.SUGARS: 40, fdc.SODIUM: 350, fdc.SATURATEDFAT: 6}
+	broccoli = fdc.NutrientValues{fdc.ENERGY: 34, fdc.PROTEIN: 2.8, fdc.FIBER: 2.6, fdc.SUGARS: 1.7, fdc.SODIUM: 33,
+		fdc.SATURATEDFAT: 0.039, 320: 31, 401: 89.2, 323: 0.78, 301: 47, 303: 0.73, 306: 316, 304: 21}
+)
+
+func TestNutriScore(t *testing.T) {
+	if ns := NutriScore(cookie, 0); ns.Score != 25 || ns.Grade != "E" {
+		t.Errorf("cookie Nutri-Score is %d %s SB 25 E", ns.Score, ns.Grade)
+	}
+	if ns := NutriScore(broccoli, 100); ns.Score != -6 || ns.Grade != "A" {
+		t.Errorf("broccoli Nutri-Score is %d %s SB -6 A", ns.Score, ns.Grade)
+	}
+	if ns := NutriScore(fdc.NutrientValues{fdc.ENERGY: 100}, 0); ns != nil {
+		t.Errorf("Expecting no Nutri-Score without sugars, saturated fat and sodium")
+	}
+}
+
+func TestHealthStar(t *testing.T) {
+	if hs := HealthStar(cookie, 0); hs.Score != 19 || hs.Stars != 1.5 {
+		t.Errorf("cookie Health Star Rating is %d %.1f SB 19 1.5", hs.Score, hs.Stars)
+	}
+	if hs := HealthStar(broccoli, 100); hs.Score != -11 || hs.Stars != 5 {
+		t.Errorf("broccoli Health Star Rating is %d %.1f SB -11 5", hs.Score, hs.Stars)
+	}
+}
+
+func TestNRF(t *testing.T) {
+	if nrf, ok := NRF(broccoli); !ok || math.Abs(nrf-211.4) > 0.5 {
+		t.Errorf("broccoli NRF9.3 is %.1f SB 211.4", nrf)
+	}
+	if _, ok := NRF(fdc.NutrientValues{fdc.PROTEIN: 10}); ok {
+		t.Errorf("Expecting no NRF9.3 without energy")
+	}
+}
+
+func TestFruitVegetables(t *testing.T) {
+	p := 30.0
+	items := []fdc.IngredientItem{{Name: "water"}, {Name: "tomato paste", Percent: &p}, {Name: "carrots", Percent: &p}}
+	if fvl := FruitVegetables(fdc.Food{}, items); fvl != 60 {
+		t.Errorf("fruit and vegetables are %.0f%% SB 60%%", fvl)
+	}
+}
@@ -0,0 +1,54 @@
+// Package similar builds nutrient vectors used to find foods with similar nutrient profiles
+package similar
+
+import (
+	"math"
+
+	"github.com/littlebunch/fdc-api/dri"
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+// energyDV is the kcal Daily Value energy is normalized to
+const energyDV = 2000
+
+// Nutrients are the nutrients in a nutrient vector in the order of the vector
+var Nutrients = []int{fdc.ENERGY, fdc.PROTEIN, fdc.TOTALFAT, fdc.CARBOHYDRATE, fdc.FIBER, fdc.SUGARS,
+	fdc.SATURATEDFAT, fdc.CHOLESTEROL, fdc.SODIUM, 306, 301, 303, 320, 401, fdc.VITAMIND}
+
+// Healthier are the nutrients a healthier food has less of by default
+var Healthier = []int{fdc.SODIUM, fdc.SATURATEDFAT, fdc.SUGARS}
+
+// Position returns the position of a nutrient in a nutrient vector
+func Position(n int) (int, bool) {
+	for i, v := range Nutrients {
+		if v == n {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// dailyValue returns the value a nutrient is normalized to.  Total sugars use the Daily Value
+// for added sugars.
+func dailyValue(n int) float64 {
+	switch n {
+	case fdc.ENERGY:
+		return energyDV
+	case fdc.SUGARS:
+		return dri.DailyValues[fdc.ADDEDSUGARS].Value
+	}
+	return dri.DailyValues[n].Value
+}
+
+// Vector returns a food's nutrient values per 100 g as fractions of their Daily Values in the
+// order of Nutrients.  Missing nutrients are 0.  Foods without energy have no vector.
+func Vector(values fdc.NutrientValues) ([]float64, bool) {
+	if _, ok := values[fdc.ENERGY]; !ok {
+		return nil, false
+	}
+	v := make([]float64, len(Nutrients))
+	for i, n := range Nutrients {
+		v[i] = math.Round(values[n]/dailyValue(n)*10000) / 10000
+	}
+	return v, true
+}
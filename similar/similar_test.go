@@ -0,0 +1,26 @@
+package similar
+
+import (
+	"testing"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+func TestVector(t *testing.T) {
+	v, ok := Vector(fdc.NutrientValues{fdc.ENERGY: 500, fdc.PROTEIN: 5, fdc.SUGARS: 25, fdc.SODIUM: 230})
+	if !ok || len(v) != len(Nutrients) {
+		t.Fatalf("vector is %v", v)
+	}
+	want := map[int]float64{fdc.ENERGY: 0.25, fdc.PROTEIN: 0.1, fdc.SUGARS: 0.5, fdc.SODIUM: 0.1, fdc.FIBER: 0}
+	for n, w := range want {
+		if i, _ := Position(n); v[i] != w {
+			t.Errorf("%d is %f SB %f", n, v[i], w)
+		}
+	}
+	if _, ok := Vector(fdc.NutrientValues{fdc.PROTEIN: 5}); ok {
+		t.Errorf("Expecting no vector without energy")
+	}
+	if _, ok := Position(fdc.ALCOHOL); ok {
+		t.Errorf("Expecting alcohol not in the vector")
+	}
+}
@@ -0,0 +1,139 @@
+// Package substitute recommends healthier alternatives to a food from foods with similar nutrient
+// profiles
+package substitute
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+// Goal is a nutrient a substitute should have less or more of
+type Goal struct {
+	Name     string
+	Nutrient int
+	Less     bool
+}
+
+// Goals are the goals a substitute can be recommended for keyed by name
+var Goals = map[string]Goal{
+	"lessSodium":       {"lessSodium", fdc.SODIUM, true},
+	"lessSaturatedFat": {"lessSaturatedFat", fdc.SATURATEDFAT, true},
+	"lessSugars":       {"lessSugars", fdc.SUGARS, true},
+	"lessAddedSugars":  {"lessAddedSugars", fdc.ADDEDSUGARS, true},
+	"lessCholesterol":  {"lessCholesterol", fdc.CHOLESTEROL, true},
+	"lessFat":          {"lessFat", fdc.TOTALFAT, true},
+	"lessEnergy":       {"lessEnergy", fdc.ENERGY, true},
+	"moreFiber":        {"moreFiber", fdc.FIBER, false},
+	"moreProtein":      {"moreProtein", fdc.PROTEIN, false},
+	"morePotassium":    {"morePotassium", 306, false},
+}
+
+// ParseGoals returns the goals for a list of goal names.  Repeated names are returned once.
+func ParseGoals(names []string) ([]Goal, error) {
+	var goals []Goal
+	seen := map[string]bool{}
+	for _, n := range names {
+		if seen[n] {
+			continue
+		}
+		seen[n] = true
+		g, ok := Goals[n]
+		if !ok {
+			var valid []string
+			for k := range Goals {
+				valid = append(valid, k)
+			}
+			sort.Strings(valid)
+			return nil, fmt.Errorf("Unrecognized goal %s.  Must be one of %s", n, strings.Join(valid, ", "))
+		}
+		goals = append(goals, g)
+	}
+	return goals, nil
+}
+
+// Candidate is a food similar to the food being replaced with it's nutrient values per 100 g
+type Candidate struct {
+	Food   fdc.SimilarFood
+	Values fdc.NutrientValues
+}
+
+// Rank returns the candidates which improve on every goal ordered by score, best first, with
+// the change in each goal's nutrient and energy.  Meta supplies nutrient names and units.
+func Rank(values fdc.NutrientValues, candidates []Candidate, goals []Goal, meta map[int]fdc.NutrientData) ([]fdc.Substitute, error) {
+	nutrients := []int{}
+	for _, g := range goals {
+		if _, ok := values[g.Nutrient]; !ok {
+			return nil, fmt.Errorf("The food has no value for nutrient %d to meet goal %s", g.Nutrient, g.Name)
+		}
+		nutrients = append(nutrients, g.Nutrient)
+	}
+	if _, ok := values[fdc.ENERGY]; ok && !contains(nutrients, fdc.ENERGY) {
+		nutrients = append(nutrients, fdc.ENERGY)
+	}
+	subs := []fdc.Substitute{}
+	for _, c := range candidates {
+		improvement, ok := improves(values, c.Values, goals)
+		if !ok {
+			continue
+		}
+		s := fdc.Substitute{FdcID: c.Food.FdcID, Description: c.Food.Description, Manufacturer: c.Food.Manufacturer,
+			Similarity: round(c.Food.Score), Improvement: round(improvement), Score: round((c.Food.Score + improvement) / 2)}
+		for _, n := range nutrients {
+			v, ok := c.Values[n]
+			if !ok {
+				continue
+			}
+			d := fdc.NutrientDelta{Nutrientno: n, Nutrient: meta[n].Nutrient, Unit: meta[n].Unit, Original: values[n], Value: v, Delta: round(v - values[n])}
+			if values[n] != 0 {
+				d.Percent = math.Round((v-values[n])/values[n]*1000) / 10
+			}
+			s.Deltas = append(s.Deltas, d)
+		}
+		subs = append(subs, s)
+	}
+	sort.SliceStable(subs, func(i, j int) bool { return subs[i].Score > subs[j].Score })
+	return subs, nil
+}
+
+// improves returns the mean fraction a candidate improves on each goal, capped at 1 per goal, if
+// it improves on all of them
+func improves(values fdc.NutrientValues, cv fdc.NutrientValues, goals []Goal) (float64, bool) {
+	sum := 0.0
+	for _, g := range goals {
+		v, ok := cv[g.Nutrient]
+		if !ok {
+			return 0, false
+		}
+		o := values[g.Nutrient]
+		change := v - o
+		if g.Less {
+			change = -change
+		}
+		if change <= 0 {
+			return 0, false
+		}
+		if o == 0 {
+			sum++
+		} else {
+			sum += math.Min(change/o, 1)
+		}
+	}
+	return sum / float64(len(goals)), true
+}
+
+func contains(a []int, n int) bool {
+	for _, v := range a {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
@@ -0,0 +1,41 @@
+package substitute
+
+import (
+	"testing"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+func TestRank(t *testing.T) {
+	goals, err := ParseGoals([]string{"lessSodium", "moreFiber"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := fdc.NutrientValues{fdc.ENERGY: 400, fdc.SODIUM: 800, fdc.FIBER: 2}
+	candidates := []Candidate{
+		{fdc.SimilarFood{FdcID: "1", Score: 0.9}, fdc.NutrientValues{fdc.ENERGY: 390, fdc.SODIUM: 700, fdc.FIBER: 3}},
+		{fdc.SimilarFood{FdcID: "2", Score: 0.7}, fdc.NutrientValues{fdc.ENERGY: 380, fdc.SODIUM: 400, fdc.FIBER: 6}},
+		{fdc.SimilarFood{FdcID: "3", Score: 0.99}, fdc.NutrientValues{fdc.ENERGY: 400, fdc.SODIUM: 900, fdc.FIBER: 8}},
+		{fdc.SimilarFood{FdcID: "4", Score: 0.95}, fdc.NutrientValues{fdc.ENERGY: 400, fdc.SODIUM: 500}},
+	}
+	subs, err := Rank(values, candidates, goals, map[int]fdc.NutrientData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 3 has more sodium and 4 no fiber value.  2 halves sodium and triples fiber, improvement 0.75
+	if len(subs) != 2 || subs[0].FdcID != "2" || subs[0].Improvement != 0.75 || subs[0].Score != 0.725 {
+		t.Fatalf("substitutes are %+v", subs)
+	}
+	if d := subs[0].Deltas; len(d) != 3 || d[0].Nutrientno != fdc.SODIUM || d[0].Delta != -400 || d[0].Percent != -50 || d[2].Nutrientno != fdc.ENERGY {
+		t.Errorf("deltas are %+v", d)
+	}
+	if _, err = Rank(fdc.NutrientValues{fdc.ENERGY: 400}, candidates, goals, map[int]fdc.NutrientData{}); err == nil {
+		t.Errorf("Expecting an error for a food without sodium")
+	}
+	if _, err = ParseGoals([]string{"moreFlavor"}); err == nil {
+		t.Errorf("Expecting an error for an unrecognized goal")
+	}
+	if goals, _ = ParseGoals([]string{"lessSodium", "moreFiber", "lessSodium"}); len(goals) != 2 {
+		t.Errorf("repeated goals are %v SB lessSodium and moreFiber", goals)
+	}
+}
@@ -0,0 +1,317 @@
+// Package units converts amounts of foods in mass, volume and household measures to grams
+package units
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+// Kind is the kind of quantity a unit measures
+type Kind int
+
+// MASS etc define the kinds of units
+const (
+	MASS Kind = iota
+	VOLUME
+)
+
+// SERVING is the conversion method for amounts in one of a food's serving descriptions
+const SERVING = "serving"
+
+func (k Kind) String() string {
+	if k == VOLUME {
+		return "volume"
+	}
+	return "mass"
+}
+
+// Unit is a unit of measure and it's size in grams for MASS units or milliliters for VOLUME units
+type Unit struct {
+	Name   string  `json:"name"`
+	Kind   Kind    `json:"kind"`
+	Factor float64 `json:"factor"`
+}
+
+// Household measures use the FDA nutrition labeling equivalents in 21 CFR 101.9(b)(5)(viii),
+// e.g. a cup is 240 ml and a tablespoon 15 ml
+var (
+	gram       = Unit{"g", MASS, 1}
+	kilogram   = Unit{"kg", MASS, 1000}
+	milligram  = Unit{"mg", MASS, 0.001}
+	microgram  = Unit{"mcg", MASS, 0.000001}
+	ounce      = Unit{"oz", MASS, 28.349523125}
+	pound      = Unit{"lb", MASS, 453.59237}
+	milliliter = Unit{"ml", VOLUME, 1}
+	liter      = Unit{"l", VOLUME, 1000}
+	teaspoon   = Unit{"tsp", VOLUME, 5}
+	tablespoon = Unit{"tbsp", VOLUME, 15}
+	fluidounce = Unit{"fl oz", VOLUME, 30}
+	cup        = Unit{"cup", VOLUME, 240}
+	pint       = Unit{"pint", VOLUME, 480}
+	quart      = Unit{"quart", VOLUME, 960}
+	gallon     = Unit{"gallon", VOLUME, 3840}
+	pinch      = Unit{"pinch", VOLUME, 5.0 / 16}
+	dash       = Unit{"dash", VOLUME, 5.0 / 8}
+)
+
+// names maps the spellings and abbreviations of a unit, including the GDSN codes found in
+// branded food servings, to the unit
+var names = map[string]Unit{
+	"g": gram, "gm": gram, "gms": gram, "gr": gram, "gram": gram, "grams": gram, "grm": gram,
+	"kg": kilogram, "kgs": kilogram, "kilogram": kilogram, "kilograms": kilogram, "kgm": kilogram,
+	"mg": milligram, "milligram": milligram, "milligrams": milligram, "mgm": milligram,
+	"mcg": microgram, "µg": microgram, "ug": microgram, "microgram": microgram, "micrograms": microgram,
+	"oz": ounce, "ozs": ounce, "ounce": ounce, "ounces": ounce, "onz": ounce,
+	"lb": pound, "lbs": pound, "pound": pound, "pounds": pound, "lbr": pound,
+	"ml": milliliter, "mls": milliliter, "milliliter": milliliter, "milliliters": milliliter, "millilitre": milliliter, "millilitres": milliliter, "mlt": milliliter, "cc": milliliter,
+	"l": liter, "liter": liter, "liters": liter, "litre": liter, "litres": liter, "ltr": liter,
+	"tsp": teaspoon, "tsps": teaspoon, "teaspoon": teaspoon, "teaspoons": teaspoon,
+	"tbsp": tablespoon, "tbsps": tablespoon, "tbs": tablespoon, "tbl": tablespoon, "tablespoon": tablespoon, "tablespoons": tablespoon,
+	"fl oz": fluidounce, "floz": fluidounce, "fluid ounce": fluidounce, "fluid ounces": fluidounce, "oza": fluidounce,
+	"c": cup, "cup": cup, "cups": cup,
+	"pt": pint, "pint": pint, "pints": pint,
+	"qt": quart, "quart": quart, "quarts": quart,
+	"gal": gallon, "gallon": gallon, "gallons": gallon,
+	"pinch": pinch, "pinches": pinch,
+	"dash": dash, "dashes": dash,
+}
+
+// measures lists the units in the UNIT dictionary
+var measures = []Unit{gram, kilogram, milligram, microgram, ounce, pound, milliliter, liter, teaspoon, tablespoon,
+	fluidounce, cup, pint, quart, gallon, pinch, dash}
+
+var (
+	spaces    = regexp.MustCompile(`\s+`)
+	leadingNo = regexp.MustCompile(`^([0-9]*\.?[0-9]+)\s*(.*)$`)
+)
+
+// Lookup returns the Unit for a unit name or abbreviation
+func Lookup(name string) (Unit, bool) {
+	u, ok := names[normalize(name)]
+	return u, ok
+}
+
+// ToGrams converts an amount of a MASS unit to grams
+func ToGrams(amount float64, unit string) (float64, error) {
+	u, ok := Lookup(unit)
+	if !ok || u.Kind != MASS {
+		return 0, fmt.Errorf("%s is not a unit of mass", unit)
+	}
+	return amount * u.Factor, nil
+}
+
+// ToMilliliters converts an amount of a VOLUME unit to milliliters
+func ToMilliliters(amount float64, unit string) (float64, error) {
+	u, ok := Lookup(unit)
+	if !ok || u.Kind != VOLUME {
+		return 0, fmt.Errorf("%s is not a unit of volume", unit)
+	}
+	return amount * u.Factor, nil
+}
+
+// Grams converts an amount of a food to grams.  The unit may be one of the food's serving
+// descriptions, a unit of mass or a unit of volume.  Volumes are converted using the density
+// of a serving measured in a unit of volume, or as 1 g/ml when the food's nutrients are
+// based on milliliters.
+func Grams(amount float64, unit string, servings []fdc.Serving) (float64, error) {
+	if amount < 0 {
+		return 0, errors.New("amount must be greater than or equal to 0")
+	}
+	if s, ok := FindServing(unit, servings); ok {
+		return amount / servingAmount(s) * float64(s.Weight), nil
+	}
+	u, ok := Lookup(unit)
+	if !ok {
+		return 0, fmt.Errorf("unrecognized unit %s", unit)
+	}
+	if u.Kind == MASS {
+		return amount * u.Factor, nil
+	}
+	if d, ok := Density(servings); ok {
+		return amount * u.Factor * d, nil
+	}
+	return 0, fmt.Errorf("cannot convert %s to grams for this food", unit)
+}
+
+// FindServing returns the serving whose description matches a unit
+func FindServing(unit string, servings []fdc.Serving) (fdc.Serving, bool) {
+	n := normalize(unit)
+	for _, s := range servings {
+		if s.Weight > 0 && normalize(s.Description) == n {
+			return s, true
+		}
+	}
+	return fdc.Serving{}, false
+}
+
+// Density returns the grams per milliliter of a food from the first of it's servings which is
+// measured in a unit of volume and has a weight.  Foods whose nutrients are based on milliliters
+// without such a serving are taken to weigh 1 g/ml.
+func Density(servings []fdc.Serving) (float64, bool) {
+	for _, s := range servings {
+		if s.Weight <= 0 {
+			continue
+		}
+		if u, ok := ServingUnit(s); ok && u.Kind == VOLUME {
+			return float64(s.Weight) / (servingAmount(s) * u.Factor), true
+		}
+	}
+	for _, s := range servings {
+		if u, ok := Lookup(s.Nutrientbasis); ok && u.Kind == VOLUME {
+			return 1, true
+		}
+	}
+	return 0, false
+}
+
+// ServingUnit returns the unit a serving is measured in.  Descriptions with a leading amount or
+// trailing preparation, e.g. "cup, chopped" or "1 tbsp", are matched on their first unit.
+func ServingUnit(s fdc.Serving) (Unit, bool) {
+	d := normalize(s.Description)
+	if m := leadingNo.FindStringSubmatch(d); m != nil {
+		d = m[2]
+	}
+	if i := strings.IndexAny(d, ",("); i > 0 {
+		d = strings.TrimSpace(d[:i])
+	}
+	if u, ok := names[d]; ok {
+		return u, true
+	}
+	// try the first two words then the first word, e.g. "fl oz bottle" or "cup whole"
+	w := strings.Split(d, " ")
+	if len(w) > 1 {
+		if u, ok := names[w[0]+" "+w[1]]; ok {
+			return u, true
+		}
+	}
+	u, ok := names[w[0]]
+	return u, ok
+}
+
+// Catalog returns the units of mass and volume with the spellings and abbreviations each is
+// recognized by
+func Catalog() []fdc.MeasureUnit {
+	var dt fdc.DocType
+	var c []fdc.MeasureUnit
+	for _, u := range measures {
+		var aliases []string
+		for n, a := range names {
+			if a == u && n != u.Name {
+				aliases = append(aliases, n)
+			}
+		}
+		sort.Strings(aliases)
+		c = append(c, fdc.MeasureUnit{Name: u.Name, Kind: u.Kind.String(), Factor: u.Factor, Aliases: aliases, Type: dt.ToString(fdc.UNIT)})
+	}
+	return c
+}
+
+// Portions lists a food's servings which have a weight with the grams in one of the serving's
+// units and the household measure it's described in
+func Portions(servings []fdc.Serving) []fdc.Portion {
+	var p []fdc.Portion
+	for _, s := range servings {
+		if s.Weight <= 0 {
+			continue
+		}
+		a := servingAmount(s)
+		fp := fdc.Portion{Description: s.Description, Amount: float32(a), Weight: s.Weight, GramsPerUnit: float64(s.Weight) / a,
+			State: s.Servingstate, Basis: s.Nutrientbasis, Datapoints: s.Datapoints}
+		if u, ok := ServingUnit(s); ok {
+			fp.Measure, fp.Kind = u.Name, u.Kind.String()
+			if u.Kind == VOLUME {
+				ml := a * u.Factor
+				fp.Milliliters = &ml
+			}
+		}
+		p = append(p, fp)
+	}
+	return p
+}
+
+// Convert converts an amount of a food to grams as Grams does and returns how the unit was
+// converted
+func Convert(amount float64, unit string, servings []fdc.Serving) (fdc.PortionConversion, error) {
+	g, err := Grams(amount, unit, servings)
+	if err != nil {
+		return fdc.PortionConversion{}, err
+	}
+	pc := fdc.PortionConversion{Amount: amount, Unit: unit, Weight: g, Method: SERVING}
+	if _, ok := FindServing(unit, servings); !ok {
+		u, _ := Lookup(unit)
+		pc.Method = u.Kind.String()
+	}
+	return pc, nil
+}
+
+// ParseMeasure splits a measure such as "1 cup", "1/2 tbsp" or "2 1/2 cups, chopped" into it's
+// amount and unit.  A measure without an amount is 1 of the unit.
+func ParseMeasure(q string) (float64, string, error) {
+	f := strings.Fields(q)
+	i := 0
+	for i < len(f) && strings.IndexAny(f[i][:1], "0123456789.") == 0 {
+		i++
+	}
+	unit := strings.Join(f[i:], " ")
+	if unit == "" {
+		return 0, "", errors.New("unit is required")
+	}
+	if i == 0 {
+		return 1, unit, nil
+	}
+	a, err := ParseAmount(strings.Join(f[:i], " "))
+	if err != nil {
+		return 0, "", err
+	}
+	return a, unit, nil
+}
+
+// ParseAmount parses a quantity such as "2", "1.5", "1/2" or "2 1/2"
+func ParseAmount(q string) (float64, error) {
+	total := 0.0
+	f := strings.Fields(q)
+	if len(f) == 0 {
+		return 0, errors.New("amount is required")
+	}
+	for _, p := range f {
+		if i := strings.Index(p, "/"); i > 0 {
+			n, err := strconv.ParseFloat(p[:i], 64)
+			if err != nil {
+				return 0, err
+			}
+			d, err := strconv.ParseFloat(p[i+1:], 64)
+			if err != nil || d == 0 {
+				return 0, fmt.Errorf("invalid fraction %s", p)
+			}
+			total += n / d
+		} else {
+			n, err := strconv.ParseFloat(p, 64)
+			if err != nil {
+				return 0, err
+			}
+			total += n
+		}
+	}
+	if math.IsNaN(total) || math.IsInf(total, 0) {
+		return 0, fmt.Errorf("amount %s must be a finite number", q)
+	}
+	return total, nil
+}
+
+func servingAmount(s fdc.Serving) float64 {
+	if s.Servingamount > 0 {
+		return float64(s.Servingamount)
+	}
+	return 1
+}
+
+func normalize(u string) string {
+	return strings.TrimSuffix(spaces.ReplaceAllString(strings.ToLower(strings.TrimSpace(u)), " "), ".")
+}
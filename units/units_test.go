@@ -0,0 +1,106 @@
+package units
+
+import (
+	"math"
+	"testing"
+
+	fdc "github.com/littlebunch/fdc-api/model"
+)
+
+var broccoli = []fdc.Serving{
+	{Description: "cup, chopped", Servingamount: 1, Weight: 91},
+	{Description: "stalk", Servingamount: 1, Weight: 151},
+}
+
+func TestGrams(t *testing.T) {
+	tests := []struct {
+		amount float64
+		unit   string
+		grams  float64
+	}{
+		{100, "g", 100},
+		{1, "oz", 28.349523125},
+		{0.5, "kg", 500},
+		{2, "Stalk", 302},
+		{1, "cup, chopped", 91},
+		{2, "cups", 182},
+		{1, "tbsp", 91.0 / 16},
+	}
+	for _, tt := range tests {
+		g, err := Grams(tt.amount, tt.unit, broccoli)
+		if err != nil {
+			t.Errorf("%f %s: %v", tt.amount, tt.unit, err)
+		} else if math.Abs(g-tt.grams) > 0.0001 {
+			t.Errorf("%f %s is %f grams SB %f", tt.amount, tt.unit, g, tt.grams)
+		}
+	}
+	if _, err := Grams(1, "cup", []fdc.Serving{{Description: "slice", Servingamount: 1, Weight: 25}}); err == nil {
+		t.Errorf("Expecting an error converting a cup with no volume servings")
+	}
+	if g, err := Grams(250, "ml", []fdc.Serving{{Nutrientbasis: "ml", Description: "MLT", Servingamount: 240, Weight: 0}}); err != nil || g != 250 {
+		t.Errorf("250 ml is %f grams SB 250 %v", g, err)
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	for q, want := range map[string]float64{"2": 2, "1.5": 1.5, "1/2": 0.5, "2 1/2": 2.5} {
+		if a, err := ParseAmount(q); err != nil || a != want {
+			t.Errorf("%s is %f SB %f %v", q, a, want, err)
+		}
+	}
+	if _, err := ParseAmount("1/0"); err == nil {
+		t.Errorf("Expecting an error parsing 1/0")
+	}
+	for _, q := range []string{"NaN", "Inf", "-Inf", "1/NaN"} {
+		if _, err := ParseAmount(q); err == nil {
+			t.Errorf("Expecting an error parsing %s", q)
+		}
+	}
+}
+
+func TestParseMeasure(t *testing.T) {
+	tests := []struct {
+		q      string
+		amount float64
+		unit   string
+	}{
+		{"1 cup", 1, "cup"},
+		{"2 1/2 cups, chopped", 2.5, "cups, chopped"},
+		{"1/2 tbsp", 0.5, "tbsp"},
+		{"stalk", 1, "stalk"},
+	}
+	for _, tt := range tests {
+		if a, u, err := ParseMeasure(tt.q); err != nil || a != tt.amount || u != tt.unit {
+			t.Errorf("%s is %f %s SB %f %s %v", tt.q, a, u, tt.amount, tt.unit, err)
+		}
+	}
+	if _, _, err := ParseMeasure("2"); err == nil {
+		t.Errorf("Expecting an error parsing a measure without a unit")
+	}
+}
+
+func TestPortions(t *testing.T) {
+	p := Portions(append(broccoli, fdc.Serving{Description: "floret", Servingamount: 1}))
+	if len(p) != 2 {
+		t.Fatalf("%d portions SB 2", len(p))
+	}
+	if p[0].Measure != "cup" || p[0].Milliliters == nil || *p[0].Milliliters != 240 || p[0].GramsPerUnit != 91 {
+		t.Errorf("cup, chopped portion is %+v", p[0])
+	}
+	if p[1].Measure != "" || p[1].Milliliters != nil {
+		t.Errorf("stalk portion SB no household measure %+v", p[1])
+	}
+	for unit, method := range map[string]string{"cup, chopped": SERVING, "oz": "mass", "tbsp": "volume"} {
+		if pc, err := Convert(1, unit, broccoli); err != nil || pc.Method != method {
+			t.Errorf("%s converted by %s SB %s %v", unit, pc.Method, method, err)
+		}
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	for _, u := range Catalog() {
+		if u.Name == "cup" && (u.Kind != "volume" || u.Factor != 240 || len(u.Aliases) != 2) {
+			t.Errorf("cup is %+v", u)
+		}
+	}
+}